@@ -0,0 +1,287 @@
+// Package admin provides HTTP API for the introspection and control of the running mux.
+//
+// Routes:
+//
+//	GET  /tasks               - task map of the mux
+//	GET  /graph?format=json   - merged task graph of the cluster in json, dot or mermaid format
+//	GET  /stats               - task stats from the monitor storage
+//	GET  /pools               - in-flight and queued counts of the async task pools
+//	GET  /status              - paused tasks, draining state and in-flight events
+//	POST /tasks/{name}/pause  - hold new events of the task
+//	POST /tasks/{name}/resume - execute held events and resume the task
+//	POST /cluster/sync        - synchronise the cluster info
+//	POST /drain               - stop receiving of new events and wait for in-flight ones
+//	POST /drain/cancel        - cancel the drain and resume receiving of new events
+//
+// Example:
+//
+//	http.Handle("/admin/", http.StripPrefix("/admin", admin.New(mux)))
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2"
+	"github.com/demdxx/asyncp/v2/graph"
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+// DefaultDrainTimeout of the graceful drain
+const DefaultDrainTimeout = 5 * time.Minute
+
+// Errors of the admin API
+var (
+	ErrNoInfoReader  = errors.New(`monitor info reader is not defined`)
+	ErrNoClusterSync = errors.New(`cluster synchronisation is not supported`)
+	ErrUnknownFormat = errors.New(`unknown graph format`)
+)
+
+// Status of the mux
+type Status struct {
+	Draining bool           `json:"draining"`
+	InFlight int64          `json:"in_flight"`
+	Paused   map[string]int `json:"paused"`
+}
+
+type clusterInfo interface {
+	InfoReader() monitor.ClusterInfoReader
+}
+
+type clusterGraph interface {
+	clusterInfo
+	TaskGraph() *graph.Graph
+}
+
+// Handler of the admin API
+type Handler struct {
+	mux     *asyncp.TaskMux
+	opts    Options
+	handler *http.ServeMux
+
+	// drainCancel stops waiting of the background drain
+	drainMx     sync.Mutex
+	drainCancel context.CancelFunc
+}
+
+// New admin handler of the mux
+func New(mux *asyncp.TaskMux, options ...Option) *Handler {
+	h := &Handler{mux: mux, handler: http.NewServeMux()}
+	for _, opt := range options {
+		opt(&h.opts)
+	}
+	if h.opts.DrainTimeout <= 0 {
+		h.opts.DrainTimeout = DefaultDrainTimeout
+	}
+	if h.opts.Context == nil {
+		h.opts.Context = context.Background()
+	}
+	if h.opts.InfoReader == nil {
+		if cluster, ok := mux.Cluster().(clusterInfo); ok {
+			h.opts.InfoReader = cluster.InfoReader()
+		}
+	}
+	h.handler.HandleFunc("GET /tasks", h.tasks)
+	h.handler.HandleFunc("GET /graph", h.graph)
+	h.handler.HandleFunc("GET /stats", h.stats)
+	h.handler.HandleFunc("GET /pools", h.pools)
+	h.handler.HandleFunc("GET /status", h.status)
+	h.handler.HandleFunc("POST /tasks/{name}/pause", h.pause)
+	h.handler.HandleFunc("POST /tasks/{name}/resume", h.resume)
+	h.handler.HandleFunc("POST /cluster/sync", h.sync)
+	h.handler.HandleFunc("POST /drain", h.drain)
+	h.handler.HandleFunc("POST /drain/cancel", h.cancelDrain)
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.opts.Auth != nil {
+		if err := h.opts.Auth(r); err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+func (h *Handler) tasks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.mux.TaskMap())
+}
+
+func (h *Handler) graph(w http.ResponseWriter, r *http.Request) {
+	g := h.mux.TaskGraph()
+	if cluster, ok := h.mux.Cluster().(clusterGraph); ok && cluster.InfoReader() != nil {
+		g = cluster.TaskGraph()
+	}
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		data, err := g.JSON()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeText(w, "application/json", string(data))
+	case "dot":
+		writeText(w, "text/vnd.graphviz", g.DOT())
+	case "mermaid":
+		writeText(w, "text/plain", g.Mermaid())
+	default:
+		writeError(w, http.StatusBadRequest, errors.Wrap(ErrUnknownFormat, format))
+	}
+}
+
+func (h *Handler) stats(w http.ResponseWriter, _ *http.Request) {
+	if h.opts.InfoReader == nil {
+		writeError(w, http.StatusNotImplemented, ErrNoInfoReader)
+		return
+	}
+	stats := map[string]*monitor.TaskInfo{}
+	for name := range h.mux.TaskMap() {
+		if strings.HasPrefix(name, "@") {
+			continue
+		}
+		info, err := h.opts.InfoReader.TaskInfo(name)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		stats[name] = info
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler) pools(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.mux.AsyncStats())
+}
+
+func (h *Handler) status(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.currentStatus())
+}
+
+func (h *Handler) pause(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !h.hasTask(name) {
+		writeError(w, http.StatusNotFound, errors.Wrap(asyncp.ErrTaskNotFound, name))
+		return
+	}
+	h.mux.PauseTask(name)
+	writeJSON(w, http.StatusOK, h.currentStatus())
+}
+
+func (h *Handler) resume(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	// Held events of the removed task are executed by the failover task
+	if !h.hasTask(name) && !h.mux.IsTaskPaused(name) {
+		writeError(w, http.StatusNotFound, errors.Wrap(asyncp.ErrTaskNotFound, name))
+		return
+	}
+	h.mux.ResumeTask(name)
+	writeJSON(w, http.StatusOK, h.currentStatus())
+}
+
+// hasTask returns true if the task is handled by the mux
+func (h *Handler) hasTask(name string) bool {
+	if strings.HasPrefix(name, "@") {
+		return false
+	}
+	_, ok := h.mux.TaskMap()[name]
+	return ok
+}
+
+func (h *Handler) sync(w http.ResponseWriter, _ *http.Request) {
+	cluster, ok := h.mux.Cluster().(interface{ SyncInfo() error })
+	if !ok {
+		writeError(w, http.StatusNotImplemented, ErrNoClusterSync)
+		return
+	}
+	if err := cluster.SyncInfo(); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// drain starts the graceful drain in background, the `wait` param waits for the end of the drain
+func (h *Handler) drain(w http.ResponseWriter, r *http.Request) {
+	timeout := h.opts.DrainTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		var err error
+		if timeout, err = time.ParseDuration(value); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(h.opts.Context, timeout)
+	h.drainMx.Lock()
+	if h.drainCancel != nil {
+		h.drainCancel()
+	}
+	h.drainCancel = cancel
+	h.drainMx.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		defer cancel()
+		err := h.mux.Drain(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("admin drain: %s", err.Error())
+		}
+		done <- err
+	}()
+	if r.URL.Query().Get("wait") == "" {
+		writeJSON(w, http.StatusAccepted, h.currentStatus())
+		return
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			writeError(w, http.StatusGatewayTimeout, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, h.currentStatus())
+	case <-r.Context().Done():
+	}
+}
+
+// cancelDrain stops the background drain and resumes receiving of new events
+func (h *Handler) cancelDrain(w http.ResponseWriter, _ *http.Request) {
+	h.drainMx.Lock()
+	if h.drainCancel != nil {
+		h.drainCancel()
+		h.drainCancel = nil
+	}
+	h.drainMx.Unlock()
+	h.mux.CancelDrain()
+	writeJSON(w, http.StatusOK, h.currentStatus())
+}
+
+func (h *Handler) currentStatus() *Status {
+	return &Status{
+		Draining: h.mux.IsDraining(),
+		InFlight: h.mux.InFlight(),
+		Paused:   h.mux.PausedTasks(),
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeText(w http.ResponseWriter, contentType, text string) {
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write([]byte(text))
+}
@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+func request(h http.Handler, method, target string, value any) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	if value != nil {
+		_ = json.Unmarshal(rec.Body.Bytes(), value)
+	}
+	return rec.Code
+}
+
+func TestAdmin(t *testing.T) {
+	mux := asyncp.NewTaskMux()
+	mux.Handle("rss", func(s string) string { return s }).Then(func(s string) string { return s })
+	h := New(mux)
+
+	var tasks map[string][]string
+	assert.Equal(t, http.StatusOK, request(h, http.MethodGet, "/tasks", &tasks))
+	assert.Equal(t, mux.TaskMap(), tasks)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graph?format=dot", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "digraph"), rec.Body.String())
+	assert.Equal(t, http.StatusBadRequest, request(h, http.MethodGet, "/graph?format=svg", nil))
+
+	var status Status
+	assert.Equal(t, http.StatusOK, request(h, http.MethodPost, "/tasks/rss/pause", &status))
+	assert.Equal(t, map[string]int{"rss": 0}, status.Paused)
+	assert.True(t, mux.IsTaskPaused("rss"))
+	var resumed Status
+	assert.Equal(t, http.StatusOK, request(h, http.MethodPost, "/tasks/rss/resume", &resumed))
+	assert.Empty(t, resumed.Paused)
+	assert.Equal(t, http.StatusNotFound, request(h, http.MethodPost, "/tasks/unknown/pause", nil))
+	assert.Equal(t, http.StatusNotFound, request(h, http.MethodPost, "/tasks/unknown/resume", nil))
+
+	assert.Equal(t, http.StatusNotImplemented, request(h, http.MethodGet, "/stats", nil))
+	assert.Equal(t, http.StatusNotImplemented, request(h, http.MethodPost, "/cluster/sync", nil))
+	assert.Equal(t, http.StatusOK, request(h, http.MethodGet, "/pools", nil))
+
+	assert.Equal(t, http.StatusOK, request(h, http.MethodPost, "/drain?wait=1&timeout=1s", &status))
+	assert.True(t, status.Draining)
+	assert.Equal(t, int64(0), status.InFlight)
+
+	assert.Equal(t, http.StatusOK, request(h, http.MethodPost, "/drain/cancel", &status))
+	assert.False(t, status.Draining)
+	assert.False(t, mux.IsDraining())
+}
+
+func TestAdminAuth(t *testing.T) {
+	h := New(asyncp.NewTaskMux(), WithAuth(func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			return errors.New("unauthorized")
+		}
+		return nil
+	}))
+	assert.Equal(t, http.StatusUnauthorized, request(h, http.MethodGet, "/tasks", nil))
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+// Option of the admin handler
+type Option func(opts *Options)
+
+// Options of the admin handler
+type Options struct {
+	// InfoReader of the task stats, the reader of the mux cluster is used by default
+	InfoReader monitor.ClusterInfoReader
+
+	// Auth checks every request before the processing
+	Auth func(r *http.Request) error
+
+	// DrainTimeout of the graceful drain
+	DrainTimeout time.Duration
+
+	// Context of the background operations like the drain, it's cancelled on shutdown
+	Context context.Context
+}
+
+// WithInfoReader of the task stats
+func WithInfoReader(reader monitor.ClusterInfoReader) Option {
+	return func(opts *Options) {
+		opts.InfoReader = reader
+	}
+}
+
+// WithAuth checks requests by the auth function
+func WithAuth(auth func(r *http.Request) error) Option {
+	return func(opts *Options) {
+		opts.Auth = auth
+	}
+}
+
+// WithContext of the background operations like the drain
+func WithContext(ctx context.Context) Option {
+	return func(opts *Options) {
+		opts.Context = ctx
+	}
+}
+
+// WithDrainTimeout of the graceful drain
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.DrainTimeout = timeout
+	}
+}
@@ -3,10 +3,16 @@ package asyncp
 import (
 	"context"
 	"log"
+	"sync"
+	"sync/atomic"
 
 	"github.com/demdxx/rpool/v2"
+	"github.com/pkg/errors"
 )
 
+// ErrTaskClosed in case of execution of the closed async task
+var ErrTaskClosed = errors.New(`task is closed`)
+
 // AsyncOption type options tune
 type AsyncOption func(opt *AsyncOptions)
 
@@ -46,12 +52,35 @@ type asyncTaskParams struct {
 	ctx   context.Context
 	event Event
 	rw    ResponseWriter
+
+	// mux processes the result of the task execution if defined
+	mux        *TaskMux
+	promise    Promise
+	isFailover bool
+}
+
+// AsyncStats of the execution pool
+type AsyncStats struct {
+	// InProcess count of the executing tasks
+	InProcess int64 `json:"in_process"`
+
+	// Queued count of the tasks waiting for the worker
+	Queued int64 `json:"queued"`
 }
 
 // AsyncTask processor
 type AsyncTask struct {
-	execPool *rpool.PoolFunc[any]
-	task     Task
+	execPool   *rpool.PoolFunc[any]
+	paramsPool sync.Pool
+	task       Task
+
+	// pending tasks in the queue and in process
+	pending atomic.Int64
+
+	// closeMx guards the pool from scheduling after the close
+	closeMx sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
 }
 
 // WrapAsyncTask as async executor
@@ -61,31 +90,80 @@ func WrapAsyncTask(task Task, options ...AsyncOption) *AsyncTask {
 		opt(&opts)
 	}
 	asyncTask := &AsyncTask{task: task}
+	asyncTask.paramsPool.New = func() any { return &asyncTaskParams{} }
 	asyncTask.execPool = opts.Pool(asyncTask.handler)
 	return asyncTask
 }
 
 // Execute the list of subtasks with input data collection.
 func (t *AsyncTask) Execute(ctx context.Context, event Event, responseWriter ResponseWriter) error {
-	t.execPool.Call(&asyncTaskParams{ctx: ctx, event: event, rw: responseWriter})
+	return t.executeAsync(ctx, event, responseWriter, nil, nil, false)
+}
+
+// executeAsync processes the result by the mux after the task execution is finished
+func (t *AsyncTask) executeAsync(ctx context.Context, event Event, responseWriter ResponseWriter, mux *TaskMux, promise Promise, isFailover bool) error {
+	t.closeMx.RLock()
+	defer t.closeMx.RUnlock()
+	if t.closed {
+		return ErrTaskClosed
+	}
+	p := t.paramsPool.Get().(*asyncTaskParams)
+	p.ctx, p.event, p.rw = ctx, RetainEvent(event), responseWriter
+	p.mux, p.promise, p.isFailover = mux, promise, isFailover
+	t.wg.Add(1)
+	t.pending.Add(1)
+	if !t.execPool.Call(p) {
+		t.pending.Add(-1)
+		t.wg.Done()
+	}
 	return nil
 }
 
 func (t *AsyncTask) handler(ctx any) {
 	p := ctx.(*asyncTaskParams)
 	defer func() {
-		err := p.rw.Release()
-		if err != nil {
-			log.Printf("release response writer: %s", err.Error())
+		if p.rw != nil {
+			if err := p.rw.Release(); err != nil {
+				log.Printf("release response writer: %s", err.Error())
+			}
 		}
+		ReleaseEvent(p.event)
+		*p = asyncTaskParams{}
+		t.paramsPool.Put(p)
+		t.pending.Add(-1)
+		t.wg.Done()
 	}()
-	err := t.task.Execute(p.ctx, p.event, p.rw)
+	// The writer is released once after the execution of any task type
+	err := t.task.Execute(p.ctx, p.event, keepResponseWriter{p.rw})
+	if p.mux != nil {
+		p.mux.afterExecute(p.promise, p.event, p.isFailover, err)
+	}
 	if err != nil {
 		panic(err)
 	}
 }
 
-// Close execution pool and finish handler processing
+// Stats of the execution pool
+func (t *AsyncTask) Stats() AsyncStats {
+	inProcess := t.execPool.InProcess()
+	return AsyncStats{InProcess: inProcess, Queued: max(t.pending.Load()-inProcess, 0)}
+}
+
+// isIdle returns true if there are no queued or executing tasks
+func (t *AsyncTask) isIdle() bool {
+	return t.pending.Load() == 0
+}
+
+// Close execution pool after the processing of all queued tasks.
+// New tasks are rejected with ErrTaskClosed.
 func (t *AsyncTask) Close() error {
+	t.closeMx.Lock()
+	if t.closed {
+		t.closeMx.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.closeMx.Unlock()
+	t.wg.Wait()
 	return t.execPool.Close()
 }
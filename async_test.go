@@ -6,6 +6,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -55,3 +56,33 @@ func TestAsyncTask(t *testing.T) {
 	assert.Equal(t, int32(50), atomic.LoadInt32(&recoverCount), `recover count`)
 	assert.Equal(t, int32(50), atomic.LoadInt32(&executeCount), `execute count`)
 }
+
+type testReleaseWriter struct {
+	ResponseHandlerFnk
+	released *atomic.Int32
+}
+
+func (w testReleaseWriter) Release() error {
+	w.released.Add(1)
+	return nil
+}
+
+type testCustomTask struct{}
+
+func (testCustomTask) Execute(context.Context, Event, ResponseWriter) error { return nil }
+
+func TestAsyncTaskReleaseWriter(t *testing.T) {
+	var released atomic.Int32
+	for _, task := range []Task{
+		testCustomTask{},
+		FuncTask(func(context.Context, Event, ResponseWriter) error { return nil }),
+	} {
+		released.Store(0)
+		asyncTask := WrapAsyncTask(task)
+		wr := testReleaseWriter{released: &released}
+		assert.NoError(t, asyncTask.Execute(context.Background(), WithPayload("test", 1), wr))
+		assert.Eventually(t, asyncTask.isIdle, time.Second, time.Millisecond)
+		assert.NoError(t, asyncTask.Close())
+		assert.Equal(t, int32(1), released.Load(), "writer is released once")
+	}
+}
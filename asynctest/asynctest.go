@@ -0,0 +1,250 @@
+// Package asynctest provides in-memory harness for the unit testing of TaskMux workflows.
+//
+// Example:
+//
+//	h := asynctest.New(t)
+//	h.Mux().Handle("rss", parseRSS).Then(saveItems)
+//	h.Emit("rss", "http://example.com/rss")
+//	h.Run()
+//	h.ExpectChain("rss", "rss.1")
+//	h.ExpectEmitted("rss.1", func(ev asyncp.Event) bool { ... })
+package asynctest
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+// DefaultMaxSteps of the message processing in one run
+const DefaultMaxSteps = 10000
+
+// ErrMaxSteps in case of infinite processing loop
+var ErrMaxSteps = errors.New(`max steps of the processing is reached`)
+
+// Harness runs the mux in-memory and records all events passed through the stream
+type Harness struct {
+	tb    testing.TB
+	mux   *asyncp.TaskMux
+	pub   *Publisher
+	clock *Clock
+
+	mx     sync.Mutex
+	queue  []Record
+	notify chan struct{}
+
+	// MaxSteps of the message processing in one run
+	MaxSteps int
+
+	// QuietPeriod to wait for the events of the async tasks
+	QuietPeriod time.Duration
+}
+
+// New harness with the mux defined by options.
+// Response publisher of the mux is replaced by the recording one.
+func New(tb testing.TB, options ...asyncp.Option) *Harness {
+	h := &Harness{
+		tb:       tb,
+		pub:      NewPublisher(),
+		clock:    NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		notify:   make(chan struct{}, 1),
+		MaxSteps: DefaultMaxSteps,
+	}
+	h.pub.notify = h.enqueue
+	h.mux = asyncp.NewTaskMux(append(options, asyncp.WithStreamResponsePublisher(h.pub))...)
+	return h
+}
+
+// Mux returns the tested task mux
+func (h *Harness) Mux() *asyncp.TaskMux { return h.mux }
+
+// Publisher returns the recording publisher of the mux
+func (h *Harness) Publisher() *Publisher { return h.pub }
+
+// Clock returns the fake clock of the harness
+func (h *Harness) Clock() *Clock { return h.clock }
+
+// Emit new event into the processing queue
+func (h *Harness) Emit(name string, payload any) *Harness {
+	ev := asyncp.WithPayload(name, payload)
+	ev.SetMux(h.mux)
+	if err := h.pub.Publish(context.Background(), ev); err != nil {
+		h.tb.Fatalf("emit %s: %s", name, err)
+	}
+	return h
+}
+
+// Run processes all events in the queue until it's empty.
+// Events of the async tasks are waited for the QuietPeriod.
+func (h *Harness) Run() error {
+	var err error
+	for step := 0; ; step++ {
+		rec, ok := h.next()
+		if !ok {
+			if h.QuietPeriod <= 0 || !h.waitQuiet() {
+				return err
+			}
+			continue
+		}
+		if step >= h.MaxSteps {
+			return multierr.Append(err, ErrMaxSteps)
+		}
+		if recErr := h.mux.Receive(message(rec.Data)); recErr != nil {
+			err = multierr.Append(err, errors.Wrap(recErr, rec.Name))
+		}
+	}
+}
+
+// MustRun processes all events and fails the test in case of error
+func (h *Harness) MustRun() *Harness {
+	h.tb.Helper()
+	if err := h.Run(); err != nil {
+		h.tb.Fatal(err)
+	}
+	return h
+}
+
+// Records returns all events passed through the stream
+func (h *Harness) Records() []Record {
+	return h.pub.Records()
+}
+
+// Emitted returns all events with the name
+func (h *Harness) Emitted(name string) []Record {
+	var list []Record
+	for _, rec := range h.Records() {
+		if rec.Name == name {
+			list = append(list, rec)
+		}
+	}
+	return list
+}
+
+// ExpectEmitted checks that the event with the name and payload was emitted.
+// The matcher can be:
+//   - nil - any payload
+//   - func(asyncp.Event) bool - custom check
+//   - any value - payload decoded into the value type must be equal to the value
+func (h *Harness) ExpectEmitted(name string, matcher any) bool {
+	h.tb.Helper()
+	records := h.Emitted(name)
+	for _, rec := range records {
+		if matchPayload(rec.Event, matcher) {
+			return true
+		}
+	}
+	if len(records) == 0 {
+		h.tb.Errorf("event %q was not emitted, emitted: %v", name, h.names())
+	} else {
+		h.tb.Errorf("event %q was emitted %d times, but no payload matches %#v", name, len(records), matcher)
+	}
+	return false
+}
+
+// ExpectNotEmitted checks that the event with the name was not emitted
+func (h *Harness) ExpectNotEmitted(name string) bool {
+	h.tb.Helper()
+	if records := h.Emitted(name); len(records) > 0 {
+		h.tb.Errorf("event %q was emitted %d times", name, len(records))
+		return false
+	}
+	return true
+}
+
+// ExpectChain checks that the events were processed one after another in the same chain
+func (h *Harness) ExpectChain(names ...string) bool {
+	h.tb.Helper()
+	if len(names) == 0 {
+		return true
+	}
+	records := h.Records()
+	for i, rec := range records {
+		if rec.Name == names[0] && h.chainFrom(records[i+1:], rec, names[1:]) {
+			return true
+		}
+	}
+	h.tb.Errorf("chain %v was not executed, emitted: %v", names, h.names())
+	return false
+}
+
+func (h *Harness) chainFrom(records []Record, prev Record, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for i, rec := range records {
+		if rec.Name == names[0] && rec.Event.ID() == prev.Event.ID() &&
+			rec.Event.HasDoneTask(prev.Name) && h.chainFrom(records[i+1:], rec, names[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Harness) names() []string {
+	records := h.Records()
+	names := make([]string, 0, len(records))
+	for _, rec := range records {
+		names = append(names, rec.Name)
+	}
+	return names
+}
+
+func (h *Harness) enqueue(rec Record) {
+	h.mx.Lock()
+	h.queue = append(h.queue, rec)
+	h.mx.Unlock()
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (h *Harness) next() (Record, bool) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if len(h.queue) == 0 {
+		return Record{}, false
+	}
+	rec := h.queue[0]
+	h.queue = slices.Delete(h.queue, 0, 1)
+	return rec, true
+}
+
+// waitQuiet returns true if new events were emitted during the quiet period
+func (h *Harness) waitQuiet() bool {
+	select {
+	case <-h.notify:
+		return true
+	case <-time.After(h.QuietPeriod):
+		return false
+	}
+}
+
+func matchPayload(ev asyncp.Event, matcher any) bool {
+	switch m := matcher.(type) {
+	case nil:
+		return true
+	case func(asyncp.Event) bool:
+		return m(ev)
+	}
+	target := reflect.New(reflect.TypeOf(matcher))
+	if err := ev.Payload().Decode(target.Interface()); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(target.Elem().Interface(), matcher)
+}
+
+type message []byte
+
+func (m message) ID() string               { return `` }
+func (m message) Context() context.Context { return context.Background() }
+func (m message) Body() []byte             { return m }
+func (m message) Ack() error               { return nil }
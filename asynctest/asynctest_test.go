@@ -0,0 +1,58 @@
+package asynctest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+type testItem struct {
+	Title string `json:"title"`
+}
+
+func TestHarness(t *testing.T) {
+	h := New(t)
+	h.Mux().Handle("rss", func(link string) []testItem {
+		return []testItem{{Title: "first " + link}, {Title: "second " + link}}
+	}).Then(func(it testItem) (string, error) {
+		return strings.ToUpper(it.Title), nil
+	})
+
+	h.Emit("rss", "link").MustRun()
+
+	h.ExpectEmitted("rss", "link")
+	h.ExpectEmitted("rss.1", testItem{Title: "second link"})
+	h.ExpectEmitted("rss.1", func(ev asyncp.Event) bool { return ev.HasDoneTask("rss") })
+	h.ExpectChain("rss", "rss.1")
+	h.ExpectNotEmitted("unknown")
+	assert.Len(t, h.Emitted("rss.1"), 2)
+
+	res := &testing.T{}
+	assert.False(t, (&Harness{tb: res, pub: h.pub}).ExpectChain("rss.1", "rss"))
+	assert.True(t, res.Failed())
+}
+
+func TestHarnessMaxSteps(t *testing.T) {
+	h := New(t)
+	h.MaxSteps = 10
+	h.Mux().Handle("loop", asyncp.Repeater(100))
+	h.Emit("loop", 1)
+	assert.ErrorIs(t, h.Run(), ErrMaxSteps)
+}
+
+func TestClock(t *testing.T) {
+	var (
+		start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock = NewClock(start)
+		ch    = clock.After(time.Minute)
+	)
+	clock.Advance(30 * time.Second)
+	assert.Len(t, ch, 0)
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, start.Add(time.Minute), <-ch)
+	assert.Equal(t, time.Minute, clock.Since(start))
+}
@@ -0,0 +1,64 @@
+package asynctest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock with manual time control for the time dependent handlers
+type Clock struct {
+	mx     sync.Mutex
+	now    time.Time
+	timers []clockTimer
+}
+
+type clockTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewClock returns clock which starts from the time
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time of the clock
+func (c *Clock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.now
+}
+
+// Since returns the time elapsed since t
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// After returns the channel which receives the time after the clock is advanced by d
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, clockTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance the clock and fire all expired timers
+func (c *Clock) Advance(d time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, tm := range c.timers {
+		if tm.at.After(c.now) {
+			timers = append(timers, tm)
+		} else {
+			tm.ch <- c.now
+		}
+	}
+	c.timers = timers
+}
@@ -0,0 +1,73 @@
+package asynctest
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+// Record of the published message
+type Record struct {
+	// Name of the event
+	Name string
+
+	// Event decoded from the published message
+	Event asyncp.Event
+
+	// Data of the message as it's sent to the stream
+	Data []byte
+}
+
+// Publisher records all published messages
+type Publisher struct {
+	mx      sync.Mutex
+	records []Record
+	notify  func(rec Record)
+}
+
+// NewPublisher returns new recording publisher
+func NewPublisher() *Publisher {
+	return &Publisher{}
+}
+
+// Publish messages into the record list
+func (p *Publisher) Publish(ctx context.Context, messages ...any) error {
+	for _, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		rec := Record{Data: data, Event: asyncp.WithPayload("", nil)}
+		if err = rec.Event.Decode(data); err != nil {
+			return err
+		}
+		rec.Name = rec.Event.Name()
+
+		p.mx.Lock()
+		p.records = append(p.records, rec)
+		notify := p.notify
+		p.mx.Unlock()
+
+		if notify != nil {
+			notify(rec)
+		}
+	}
+	return nil
+}
+
+// Records returns the list of published messages
+func (p *Publisher) Records() []Record {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return slices.Clone(p.records)
+}
+
+// Reset the record list
+func (p *Publisher) Reset() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.records = p.records[:0]
+}
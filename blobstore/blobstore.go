@@ -0,0 +1,52 @@
+// Package blobstore provides storages of the large payloads
+// which are not sent through the message broker (claim-check pattern).
+package blobstore
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound in case of blob is not exists or expired
+var ErrNotFound = errors.New(`blob not found`)
+
+// BlobStore describes storage of the payload data
+type BlobStore interface {
+	// Put data with the key, zero ttl means no expiration
+	Put(ctx context.Context, key string, data []byte, ttl time.Duration) error
+
+	// Get data by the key
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete data by the key, deletion of not existing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// PrefixDeleter removes all blobs with the key prefix
+type PrefixDeleter interface {
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Cleaner removes expired blobs from the storage
+type Cleaner interface {
+	Cleanup(ctx context.Context) error
+}
+
+// CleanupEvery runs cleanup of the storage with interval until context is done
+func CleanupEvery(ctx context.Context, cleaner Cleaner, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := cleaner.Cleanup(ctx); err != nil {
+				log.Printf("blobstore cleanup: %s", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package blobstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobStores(t *testing.T) {
+	fs, err := NewFS(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx := context.Background()
+	for name, store := range map[string]interface {
+		BlobStore
+		Cleaner
+		PrefixDeleter
+	}{"memory": NewMemory(), "fs": fs} {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, store.Put(ctx, "blob", []byte("data"), 0))
+			assert.NoError(t, store.Put(ctx, "expired", []byte("data"), time.Millisecond))
+			time.Sleep(time.Millisecond * 5)
+
+			data, err := store.Get(ctx, "blob")
+			assert.NoError(t, err)
+			assert.Equal(t, "data", string(data))
+
+			_, err = store.Get(ctx, "expired")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			assert.NoError(t, store.Cleanup(ctx))
+			assert.NoError(t, store.Delete(ctx, "blob"))
+			assert.NoError(t, store.Delete(ctx, "blob"))
+			_, err = store.Get(ctx, "blob")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			// Returned data doesn't share the memory with the store
+			assert.NoError(t, store.Put(ctx, "chain_1", []byte("data"), 0))
+			assert.NoError(t, store.Put(ctx, "chain_2", []byte("data"), 0))
+			assert.NoError(t, store.Put(ctx, "other_1", []byte("data"), 0))
+			data, _ = store.Get(ctx, "chain_1")
+			data[0] = 'D'
+			data, _ = store.Get(ctx, "chain_1")
+			assert.Equal(t, "data", string(data))
+
+			assert.NoError(t, store.DeletePrefix(ctx, "chain_"))
+			_, err = store.Get(ctx, "chain_2")
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = store.Get(ctx, "other_1")
+			assert.NoError(t, err)
+		})
+	}
+	assert.ErrorIs(t, fs.Put(ctx, "../blob", nil, 0), ErrInvalidKey)
+}
@@ -0,0 +1,136 @@
+package blobstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidKey in case of key contains path elements
+var ErrInvalidKey = errors.New(`invalid blob key`)
+
+// noExpiration lifetime of the blob without TTL
+const noExpiration = time.Hour * 24 * 365 * 100
+
+// FS storage of blobs in the local or mounted shared directory.
+// Expiration time of the blob is stored as the modification time of the file.
+type FS struct {
+	dir string
+}
+
+// NewFS returns filesystem blob storage in the directory
+func NewFS(dir string) (*FS, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FS{dir: dir}, nil
+}
+
+// Put data with the key
+func (s *FS) Put(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	filename, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		ttl = noExpiration
+	}
+	// Write into the temporary file to prevent reading of the partial data
+	tmpFilename := filename + ".tmp"
+	if err = os.WriteFile(tmpFilename, data, 0o644); err != nil {
+		return err
+	}
+	expiredAt := time.Now().Add(ttl)
+	if err = os.Chtimes(tmpFilename, expiredAt, expiredAt); err != nil {
+		_ = os.Remove(tmpFilename)
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
+
+// Get data by the key
+func (s *FS) Get(_ context.Context, key string) ([]byte, error) {
+	filename, err := s.filename(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(filename)
+	if os.IsNotExist(err) || (err == nil && time.Now().After(stat.ModTime())) {
+		return nil, errors.Wrap(ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filename)
+}
+
+// Delete data by the key
+func (s *FS) Delete(_ context.Context, key string) error {
+	filename, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix removes all blobs with the key prefix
+func (s *FS) DeletePrefix(ctx context.Context, prefix string) error {
+	if _, err := s.filename(prefix); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		if err = os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cleanup expired blobs
+func (s *FS) Cleanup(ctx context.Context) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !now.After(info.ModTime()) {
+			continue
+		}
+		if err = os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FS) filename(key string) (string, error) {
+	if key == `` || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, `.`) {
+		return ``, errors.Wrap(ErrInvalidKey, key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
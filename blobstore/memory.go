@@ -0,0 +1,93 @@
+package blobstore
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type memoryItem struct {
+	data      []byte
+	expiredAt time.Time
+}
+
+func (it *memoryItem) isExpired(now time.Time) bool {
+	return !it.expiredAt.IsZero() && now.After(it.expiredAt)
+}
+
+// Memory storage of blobs for the single process and tests
+type Memory struct {
+	mx    sync.RWMutex
+	items map[string]*memoryItem
+}
+
+// NewMemory returns in-memory blob storage
+func NewMemory() *Memory {
+	return &Memory{items: map[string]*memoryItem{}}
+}
+
+// Put data with the key
+func (m *Memory) Put(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	item := &memoryItem{data: append([]byte(nil), data...)}
+	if ttl > 0 {
+		item.expiredAt = time.Now().Add(ttl)
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.items[key] = item
+	return nil
+}
+
+// Get data by the key
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mx.RLock()
+	item := m.items[key]
+	m.mx.RUnlock()
+	if item == nil || item.isExpired(time.Now()) {
+		return nil, errors.Wrap(ErrNotFound, key)
+	}
+	return append([]byte(nil), item.data...), nil
+}
+
+// Delete data by the key
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+// DeletePrefix removes all blobs with the key prefix
+func (m *Memory) DeletePrefix(_ context.Context, prefix string) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for key := range m.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.items, key)
+		}
+	}
+	return nil
+}
+
+// Cleanup expired blobs
+func (m *Memory) Cleanup(_ context.Context) error {
+	now := time.Now()
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for key, item := range m.items {
+		if item.isExpired(now) {
+			delete(m.items, key)
+		}
+	}
+	return nil
+}
+
+// Len returns count of the stored blobs
+func (m *Memory) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.items)
+}
@@ -0,0 +1,350 @@
+package asyncp
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2/journal"
+)
+
+// DefaultReplyTo event name of the chain results
+const DefaultReplyTo = "reply"
+
+// DefaultCallTimeout of the chain result waiting
+const DefaultCallTimeout = time.Minute
+
+var (
+	// ErrCallTimeout in case of the chain result is not received in time
+	ErrCallTimeout = errors.New(`call timeout`)
+
+	// ErrUnknownReply in case of the reply of other caller
+	ErrUnknownReply = errors.New(`unknown reply`)
+)
+
+// Future of the chain result
+type Future struct {
+	id      string
+	once    sync.Once
+	done    chan struct{}
+	payload Payload
+	err     error
+}
+
+func newFuture(id string) *Future {
+	return &Future{id: id, done: make(chan struct{})}
+}
+
+// ID of the submitted event which is the correlation ID of the result
+func (f *Future) ID() string {
+	return f.id
+}
+
+// Done is closed when the result is received
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result of the chain, it blocks until the result is received
+func (f *Future) Result() (Payload, error) {
+	<-f.done
+	return f.payload, f.err
+}
+
+// Wait for the chain result until the context is done
+func (f *Future) Wait(ctx context.Context) (Payload, error) {
+	select {
+	case <-f.done:
+		return f.payload, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Decode result of the chain into the target
+func (f *Future) Decode(ctx context.Context, target any) error {
+	payload, err := f.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	return payload.Decode(target)
+}
+
+func (f *Future) resolve(payload Payload, err error) {
+	f.once.Do(func() {
+		f.payload, f.err = payload, err
+		close(f.done)
+	})
+}
+
+// Submit the event to the local task chain and returns the future of the chain result.
+// The last task of the chain writes the result to the future,
+// the first error of the chain is returned as the result error.
+// The context limits the waiting of the result, the future is resolved
+// with ErrCallTimeout if the result is not received in DefaultCallTimeout
+// or the time of CallWithTimeout option.
+func (srv *TaskMux) Submit(ctx context.Context, eventName string, payload any, options ...CallOption) *Future {
+	var (
+		opts callOptions
+		ev   = WithPayload(eventName, srv.responsePayload(nil, payload))
+		id   = ev.ID().String()
+		fut  = newFuture(id)
+	)
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.timeout <= 0 {
+		opts.timeout = DefaultCallTimeout
+	}
+	ev.SetMux(srv)
+	ev.SetReply(id, "")
+	srv.futures.Store(id, fut)
+	go func() {
+		if err := srv.ExecuteEvent(ev); err != nil {
+			srv.resolveFuture(id, nil, err)
+		}
+	}()
+	go func() {
+		timer := time.NewTimer(opts.timeout)
+		defer timer.Stop()
+		select {
+		case <-fut.done:
+		case <-timer.C:
+			srv.resolveFuture(id, nil, errors.Wrap(ErrCallTimeout, eventName))
+		case <-ctx.Done():
+			srv.resolveFuture(id, nil, ctx.Err())
+		}
+	}()
+	return fut
+}
+
+func (srv *TaskMux) resolveFuture(correlationID string, payload Payload, err error) bool {
+	fut, ok := srv.futures.LoadAndDelete(correlationID)
+	if ok {
+		fut.(*Future).resolve(payload, err)
+	}
+	return ok
+}
+
+// reply writes the chain result to the local future of Submit,
+// the reply publisher of the mux or the response stream
+func (srv *TaskMux) reply(ctx context.Context, prom Promise, parent Event, value any, stream Publisher) error {
+	ev, _ := responseEvent(srv, prom, parent, parent.ReplyTo(), value, false, nil)
+	if err := srv.validateOutput(prom, ev); err != nil {
+		return err
+	}
+	srv.trackResult(ev)
+	srv.journalEvent(journal.DirectionOut, ev)
+	if srv.resolveFuture(parent.CorrelationID(), ev.Payload(), ev.Err()) {
+		return nil
+	}
+	if srv.replyPublisher != nil {
+		stream = srv.replyPublisher
+	}
+	if stream == nil || parent.ReplyTo() == "" {
+		return nil
+	}
+	return publishEvent(ctx, stream, ev)
+}
+
+// replyError of the failed task to the requester
+func (srv *TaskMux) replyError(ctx context.Context, prom Promise, event Event, err error) {
+	if event.CorrelationID() == "" {
+		return
+	}
+	var stream Publisher
+	if srv.responseFactory != nil {
+		wr := srv.responseFactory.Borrow(ctx, prom, event)
+		if sw, _ := wr.(*responseStreamWriter); sw != nil {
+			stream = sw.wstream
+		}
+		defer func() { _ = wr.Release() }()
+	}
+	if err := srv.reply(ctx, prom, event, event.WithError(err), stream); err != nil {
+		log.Printf("reply error of %s: %s", event.Name(), err.Error())
+	}
+}
+
+// CallOption of the request
+type CallOption func(opts *callOptions)
+
+type callOptions struct {
+	replyTo string
+	timeout time.Duration
+	mux     *TaskMux
+}
+
+// CallWithReplyTo sets the event name of the chain result
+func CallWithReplyTo(name string) CallOption {
+	return func(opts *callOptions) {
+		opts.replyTo = name
+	}
+}
+
+// CallWithTimeout of the chain result waiting
+func CallWithTimeout(timeout time.Duration) CallOption {
+	return func(opts *callOptions) {
+		opts.timeout = timeout
+	}
+}
+
+// CallWithMux uses codecs and envelope options of the mux for the requests and replies
+func CallWithMux(mux *TaskMux) CallOption {
+	return func(opts *callOptions) {
+		opts.mux = mux
+	}
+}
+
+// Caller sends events to the task chains and awaits results from the reply stream
+type Caller struct {
+	opts      callOptions
+	pub       Publisher
+	replies   Subscriber
+	allocator EventAllocator
+	calls     sync.Map
+
+	listenOnce sync.Once
+	listenErr  error
+	cancel     context.CancelFunc
+}
+
+// NewCaller returns the caller with the reply stream subscriber.
+// The subscriber is listened in background after the first call until the caller is closed.
+// Replies of other callers are returned to the stream by NackMessage
+// or left without acknowledgment if the transport doesn't support it.
+func NewCaller(pub Publisher, replies Subscriber, options ...CallOption) *Caller {
+	c := &Caller{pub: pub, replies: replies}
+	for _, opt := range options {
+		opt(&c.opts)
+	}
+	if c.opts.mux != nil {
+		c.allocator = c.opts.mux.eventAllocator
+	} else {
+		c.allocator = newDefaultEventAllocator()
+	}
+	return c
+}
+
+// Call publishes the event with the correlation ID and the reply event name
+// and returns the result of the chain from the reply stream or error.
+// The caller is closed after the reply, use Caller for the multiple calls.
+func Call(ctx context.Context, pub Publisher, replies Subscriber, eventName string, payload any, options ...CallOption) (Payload, error) {
+	c := NewCaller(pub, replies, options...)
+	defer func() {
+		if err := c.Close(); err != nil {
+			log.Printf("close caller: %s", err.Error())
+		}
+	}()
+	return c.call(ctx, eventName, payload)
+}
+
+// Call publishes the event and returns the result of the chain
+func (c *Caller) Call(ctx context.Context, eventName string, payload any, options ...CallOption) (Payload, error) {
+	return c.call(ctx, eventName, payload, options...)
+}
+
+// Submit publishes the event and returns the future of the chain result.
+// The future is resolved with ErrCallTimeout if the result is not received in time.
+func (c *Caller) Submit(ctx context.Context, eventName string, payload any, options ...CallOption) (*Future, error) {
+	return c.submit(ctx, eventName, payload, options...)
+}
+
+func (c *Caller) call(ctx context.Context, eventName string, payload any, options ...CallOption) (Payload, error) {
+	fut, err := c.submit(ctx, eventName, payload, options...)
+	if err != nil {
+		return nil, err
+	}
+	return fut.Result()
+}
+
+func (c *Caller) submit(ctx context.Context, eventName string, payload any, options ...CallOption) (*Future, error) {
+	opts := c.opts
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.replyTo == "" {
+		opts.replyTo = DefaultReplyTo
+	}
+	if opts.timeout <= 0 {
+		opts.timeout = DefaultCallTimeout
+	}
+	if err := c.listen(); err != nil {
+		return nil, err
+	}
+
+	ev := WithPayload(eventName, opts.mux.responsePayload(nil, payload))
+	ev.SetMux(opts.mux)
+	ev.SetReply(ev.ID().String(), opts.replyTo)
+
+	fut := newFuture(ev.CorrelationID())
+	c.calls.Store(fut.id, fut)
+
+	if err := publishEvent(ctx, c.pub, ev); err != nil {
+		c.calls.Delete(fut.id)
+		return nil, err
+	}
+
+	go func() {
+		timer := time.NewTimer(opts.timeout)
+		defer timer.Stop()
+		select {
+		case <-fut.done:
+		case <-timer.C:
+			c.resolve(fut.id, nil, errors.Wrap(ErrCallTimeout, eventName))
+		case <-ctx.Done():
+			c.resolve(fut.id, nil, ctx.Err())
+		}
+	}()
+	return fut, nil
+}
+
+func (c *Caller) resolve(correlationID string, payload Payload, err error) bool {
+	fut, ok := c.calls.LoadAndDelete(correlationID)
+	if ok {
+		fut.(*Future).resolve(payload, err)
+	}
+	return ok
+}
+
+func (c *Caller) listen() error {
+	c.listenOnce.Do(func() {
+		var ctx context.Context
+		ctx, c.cancel = context.WithCancel(context.Background())
+		if c.listenErr = c.replies.Subscribe(ctx, c); c.listenErr != nil {
+			return
+		}
+		go func() {
+			if err := c.replies.Listen(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("listen replies: %s", err.Error())
+			}
+		}()
+	})
+	return c.listenErr
+}
+
+// Receive the reply message, replies of other callers are returned to the stream
+func (c *Caller) Receive(msg Message) error {
+	ev, err := c.allocator.Decode(msg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.allocator.Release(ev) }()
+	if !c.resolve(ev.CorrelationID(), ev.Payload(), ev.Err()) {
+		if nm, ok := msg.(NackMessage); ok {
+			return nm.Nack()
+		}
+		return errors.Wrap(ErrUnknownReply, ev.CorrelationID())
+	}
+	return msg.Ack()
+}
+
+// Close the reply stream subscriber
+func (c *Caller) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return c.replies.Close()
+}
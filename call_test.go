@@ -0,0 +1,131 @@
+package asyncp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geniusrabbit/notificationcenter/v2/gochan"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxSubmit(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		mux         = NewTaskMux()
+	)
+	defer cancel()
+	mux.Handle("sum", func(v []int) (*int, error) {
+		if len(v) == 0 {
+			return nil, errors.New("empty")
+		}
+		sum := 0
+		for _, i := range v {
+			sum += i
+		}
+		return &sum, nil
+	}, WithSingleResponse()).
+		Then(func(sum int) int { return sum * 2 }).
+		Then(FuncTask(func(ctx context.Context, event Event, rw ResponseWriter) error {
+			return rw.WriteResonse(event)
+		}).Async())
+
+	var result int
+	assert.NoError(t, mux.Submit(ctx, "sum", []int{1, 2, 3}).Decode(ctx, &result))
+	assert.Equal(t, 12, result)
+
+	_, err := mux.Submit(ctx, "sum", []int{}).Wait(ctx)
+	assert.EqualError(t, err, "empty")
+
+	_, err = mux.Submit(ctx, "sum", "invalid").Wait(ctx)
+	assert.Error(t, err)
+
+	mux.Handle("silent", func(int) {})
+	_, err = mux.Submit(ctx, "silent", 1, CallWithTimeout(time.Millisecond*10)).Wait(ctx)
+	assert.ErrorIs(t, err, ErrCallTimeout)
+}
+
+func TestCall(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		requests    = gochan.New(10)
+		replies     = gochan.New(10)
+		mux         = NewTaskMux(
+			WithStreamResponsePublisher(requests.Publisher()),
+			WithReplyPublisher(replies.Publisher()),
+		)
+	)
+	defer cancel()
+	mux.Handle("upper", func(s string) (*string, error) {
+		if s == "" {
+			return nil, errors.New("empty")
+		}
+		s = strings.ToUpper(s)
+		return &s, nil
+	}).Then(func(s string) string { return s + "!" })
+
+	assert.NoError(t, requests.Subscribe(ctx, mux))
+	go func() { _ = requests.Listen(ctx) }()
+
+	caller := NewCaller(requests.Publisher(), replies, CallWithTimeout(time.Second))
+	defer func() { _ = caller.Close() }()
+
+	payload, err := caller.Call(ctx, "upper", "hello")
+	if assert.NoError(t, err) {
+		var res string
+		assert.NoError(t, payload.Decode(&res))
+		assert.Equal(t, "HELLO!", res)
+	}
+
+	_, err = caller.Call(ctx, "upper", "")
+	assert.EqualError(t, err, "empty")
+
+	_, err = caller.Call(ctx, "unknown", "", CallWithTimeout(time.Millisecond*10))
+	assert.ErrorIs(t, err, ErrCallTimeout)
+}
+
+func TestCallOneShot(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		requests    = gochan.New(10)
+		replies     = gochan.New(10)
+		mux         = NewTaskMux(WithReplyPublisher(replies.Publisher()))
+	)
+	defer cancel()
+	mux.Handle("upper", func(s string) string { return strings.ToUpper(s) })
+	assert.NoError(t, requests.Subscribe(ctx, mux))
+	go func() { _ = requests.Listen(ctx) }()
+
+	payload, err := Call(ctx, requests.Publisher(), replies, "upper", "hello", CallWithTimeout(time.Second))
+	if assert.NoError(t, err) {
+		var res string
+		assert.NoError(t, payload.Decode(&res))
+		assert.Equal(t, "HELLO", res)
+	}
+}
+
+type nackMessage struct {
+	message
+	nacked bool
+}
+
+func (m *nackMessage) Nack() error {
+	m.nacked = true
+	return nil
+}
+
+func TestCallerUnknownReply(t *testing.T) {
+	caller := NewCaller(nil, gochan.New(1))
+	ev := WithPayload("reply", "result")
+	ev.SetReply("other", DefaultReplyTo)
+	data, err := ev.Encode()
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, caller.Receive(message(data)), ErrUnknownReply)
+
+	msg := &nackMessage{message: message(data)}
+	assert.NoError(t, caller.Receive(msg))
+	assert.True(t, msg.nacked, "reply of other caller must be returned to the stream")
+}
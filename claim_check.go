@@ -0,0 +1,158 @@
+package asyncp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2/blobstore"
+)
+
+// ErrBlobStoreUndefined in case of payload reference without blob store
+var ErrBlobStoreUndefined = errors.New(`blob store is undefined`)
+
+// DefaultBlobTTL of the payload blobs if TTL of the claim-check is not defined
+const DefaultBlobTTL = 24 * time.Hour
+
+// blobKeySeparator between the chain ID and the blob ID in the reference
+const blobKeySeparator = "_"
+
+// isClaimCheck returns true if payload have to be moved into the blob store
+func (opts *envelopeOptions) isClaimCheck(size int) bool {
+	return opts != nil && opts.blobStore != nil && size > 0 && size > opts.blobThreshold
+}
+
+// putBlob stores payload data of the chain and returns the reference.
+// The reference starts from the chain ID, so all blobs of the chain are removed together.
+func (opts *envelopeOptions) putBlob(chainID uuid.UUID, data []byte) (string, error) {
+	ref := uuid.NewString()
+	if chainID != uuid.Nil {
+		ref = chainID.String() + blobKeySeparator + ref
+	}
+	ttl := opts.blobTTL
+	if ttl <= 0 {
+		ttl = DefaultBlobTTL
+	}
+	if err := opts.blobStore.Put(context.Background(), ref, data, ttl); err != nil {
+		return ``, errors.Wrap(err, `put payload blob`)
+	}
+	return ref, nil
+}
+
+// releaseBlobs of the finished chain if the store removes blobs by the prefix,
+// otherwise blobs are removed when TTL runs out
+func (opts *envelopeOptions) releaseBlobs(ctx context.Context, chainID uuid.UUID) error {
+	if opts == nil || opts.blobStore == nil || chainID == uuid.Nil {
+		return nil
+	}
+	deleter, ok := opts.blobStore.(blobstore.PrefixDeleter)
+	if !ok {
+		return nil
+	}
+	return deleter.DeletePrefix(ctx, chainID.String()+blobKeySeparator)
+}
+
+// blobPayload refers to the payload data in the blob store.
+// Data is fetched on the first access only.
+type blobPayload struct {
+	mx          sync.Mutex
+	ref         string
+	contentType string
+	compression string
+	keyID       string
+	version     int
+	eventID     uuid.UUID
+	eventName   string
+	codec       Codec
+	opts        *envelopeOptions
+	upcast      func(data []byte, c Codec) ([]byte, error)
+	data        []byte
+	loaded      bool
+
+	// ctx of the task execution which fetches the blob
+	ctx context.Context
+}
+
+func newBlobPayload(ref string, item *encodeEvent, c Codec, opts *envelopeOptions) (*blobPayload, error) {
+	if opts == nil || opts.blobStore == nil {
+		return nil, errors.Wrap(ErrBlobStoreUndefined, ref)
+	}
+	return &blobPayload{
+		ref:         ref,
+		contentType: item.ContentType,
+		compression: item.Compression,
+		keyID:       item.KeyID,
+		version:     item.PayloadVersion,
+		eventID:     item.ID,
+		eventName:   item.Name,
+		codec:       c,
+		opts:        opts,
+	}, nil
+}
+
+// canForward returns true if the event can be sent with the same reference.
+// Encrypted payload is bound to the event ID and name, so it's reencoded for the new event.
+func (p *blobPayload) canForward(ev *event, opts *envelopeOptions) bool {
+	if opts != nil && opts.blobStore != p.opts.blobStore {
+		return false
+	}
+	return p.keyID == `` || (p.eventID == ev.id && p.eventName == ev.name)
+}
+
+// Encode payload object into bytes
+func (p *blobPayload) Encode() ([]byte, error) {
+	return p.load()
+}
+
+// Decode payload data into the target
+func (p *blobPayload) Decode(target any) error {
+	data, err := p.load()
+	if err != nil {
+		return err
+	}
+	return p.codec.Unmarshal(data, target)
+}
+
+// ContentType of the payload data
+func (p *blobPayload) ContentType() string {
+	return p.codec.ContentType()
+}
+
+func (p *blobPayload) load() ([]byte, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.loaded {
+		return p.data, nil
+	}
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data, err := p.opts.blobStore.Get(ctx, p.ref)
+	if err != nil {
+		return nil, errors.Wrap(err, `get payload blob`)
+	}
+	if data, err = p.opts.decrypt(data, p.keyID, p.eventID, p.eventName); err != nil {
+		return nil, err
+	}
+	if data, err = decompressPayload(data, p.compression); err != nil {
+		return nil, err
+	}
+	if p.upcast != nil {
+		if data, err = p.upcast(data, p.codec); err != nil {
+			return nil, err
+		}
+	}
+	p.data, p.loaded = data, true
+	return data, nil
+}
+
+// setContext of the task execution which fetches the blob
+func (p *blobPayload) setContext(ctx context.Context) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.ctx = ctx
+}
@@ -0,0 +1,427 @@
+package asyncp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2/codec"
+)
+
+// CloudEvents specification constants
+const (
+	CloudEventsSpecVersion = "1.0"
+
+	// CloudEventsKafkaPrefix of the binary mode headers for Kafka
+	CloudEventsKafkaPrefix = "ce_"
+
+	// CloudEventsHeaderPrefix of the binary mode headers for HTTP and NATS
+	CloudEventsHeaderPrefix = "ce-"
+
+	cloudEventsDefaultSource = "asyncp"
+)
+
+// ErrNotCloudEvent in case of message is not in CloudEvents format
+var ErrNotCloudEvent = errors.New(`message is not a CloudEvent`)
+
+// CloudEventsMode of the event representation in the message
+type CloudEventsMode int
+
+// CloudEvents modes...
+const (
+	// CloudEventsStructured encodes the whole event into the JSON message body
+	CloudEventsStructured CloudEventsMode = iota
+	// CloudEventsBinary puts attributes into the message headers and payload into the body
+	CloudEventsBinary
+)
+
+// CloudEventsOptions of the CloudEvents encoding
+type CloudEventsOptions struct {
+	// Source of the events (URI-reference)
+	Source string
+
+	// Mode of the encoding. Binary mode requires HeaderPublisher,
+	// other publishers receive events in structured mode.
+	Mode CloudEventsMode
+
+	// HeaderPrefix of the binary mode attributes, "ce-" by default
+	HeaderPrefix string
+}
+
+func (opts *CloudEventsOptions) source() string {
+	if opts == nil || opts.Source == "" {
+		return cloudEventsDefaultSource
+	}
+	return opts.Source
+}
+
+func (opts *CloudEventsOptions) headerPrefix() string {
+	if opts == nil || opts.HeaderPrefix == "" {
+		return CloudEventsHeaderPrefix
+	}
+	return opts.HeaderPrefix
+}
+
+// HeaderMessage is the message of transport with headers support
+type HeaderMessage interface {
+	Message
+	Headers() map[string]string
+}
+
+// HeaderPublisher is the publisher of transport with headers support
+type HeaderPublisher interface {
+	Publisher
+	PublishWithHeaders(ctx context.Context, headers map[string]string, body []byte) error
+}
+
+// cloudEvent structured mode representation with asyncp extensions
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+
+	// Extensions of the asyncp envelope
+	DoneTasks        string `json:"apdone,omitempty"`
+	SendCount        int    `json:"apsent,omitempty"`
+	RetranslateCount int    `json:"apretrans,omitempty"`
+	Complete         bool   `json:"apcomplete,omitempty"`
+	Err              string `json:"aperror,omitempty"`
+	ErrCode          string `json:"aperrorcode,omitempty"`
+	Compression      string `json:"apcompress,omitempty"`
+	KeyID            string `json:"apkeyid,omitempty"`
+	PayloadRef       string `json:"apref,omitempty"`
+	PayloadVersion   int    `json:"apversion,omitempty"`
+	CorrelationID    string `json:"apcorrelation,omitempty"`
+	ReplyTo          string `json:"apreplyto,omitempty"`
+}
+
+func newCloudEvent(item *encodeEvent, opts *CloudEventsOptions) *cloudEvent {
+	ce := &cloudEvent{
+		SpecVersion:      CloudEventsSpecVersion,
+		ID:               item.ID.String(),
+		Source:           opts.source(),
+		Type:             item.Name,
+		DataContentType:  codec.Or(nil).ContentType(),
+		DoneTasks:        strings.Join(item.DoneEvents, ","),
+		SendCount:        item.SendCount,
+		RetranslateCount: item.RetranslateCount,
+		Complete:         item.Complete,
+		Err:              item.Err,
+		ErrCode:          item.ErrCode,
+		Compression:      item.Compression,
+		KeyID:            item.KeyID,
+		PayloadRef:       item.PayloadRef,
+		PayloadVersion:   item.PayloadVersion,
+		CorrelationID:    item.CorrelationID,
+		ReplyTo:          item.ReplyTo,
+	}
+	if item.ContentType != "" {
+		ce.DataContentType = item.ContentType
+	}
+	if !item.CreatedAt.IsZero() {
+		ce.Time = &item.CreatedAt
+	}
+	return ce
+}
+
+// toEnvelope converts CloudEvent into the internal envelope
+func (ce *cloudEvent) toEnvelope(item *encodeEvent) error {
+	if ce.SpecVersion != CloudEventsSpecVersion {
+		return errors.Wrap(ErrUnsupportedEnvelopeVersion, "CloudEvents "+ce.SpecVersion)
+	}
+	id, err := uuid.Parse(ce.ID)
+	if err != nil {
+		// Foreign producers can use any ID unique in the scope of the source
+		id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(ce.Source+"#"+ce.ID))
+	}
+	*item = encodeEvent{
+		ID:               id,
+		Name:             ce.Type,
+		Payload:          ce.DataBase64,
+		ContentType:      normalizeContentType(ce.DataContentType),
+		Compression:      ce.Compression,
+		KeyID:            ce.KeyID,
+		PayloadRef:       ce.PayloadRef,
+		PayloadVersion:   ce.PayloadVersion,
+		CorrelationID:    ce.CorrelationID,
+		ReplyTo:          ce.ReplyTo,
+		Complete:         ce.Complete,
+		SendCount:        ce.SendCount,
+		RetranslateCount: ce.RetranslateCount,
+		Err:              ce.Err,
+		ErrCode:          ce.ErrCode,
+	}
+	if len(ce.Data) > 0 {
+		// Data in JSON form is always decoded by JSON codec
+		item.Payload = ce.Data
+		if _, err := codec.Lookup(item.ContentType); err != nil {
+			item.ContentType = ""
+		}
+	}
+	if ce.DoneTasks != "" {
+		item.DoneEvents = strings.Split(ce.DoneTasks, ",")
+	}
+	if ce.Time != nil {
+		item.CreatedAt = *ce.Time
+	}
+	return nil
+}
+
+// encodeCloudEvent in structured mode
+func encodeCloudEvent(item *encodeEvent, opts *CloudEventsOptions) ([]byte, error) {
+	ce := newCloudEvent(item, opts)
+	if isJSONData(item) {
+		ce.Data = json.RawMessage(item.Payload)
+	} else {
+		ce.DataBase64 = item.Payload
+	}
+	var buff bytes.Buffer
+	if err := json.NewEncoder(&buff).Encode(ce); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// isCloudEvent checks if the message is CloudEvent in structured mode
+func isCloudEvent(data []byte) bool {
+	if !bytes.Contains(data, []byte(`"specversion"`)) {
+		return false
+	}
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.SpecVersion != ""
+}
+
+// decodeCloudEvent in structured mode
+func decodeCloudEvent(data []byte, item *encodeEvent) error {
+	var ce cloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return errors.Wrap(err, `decode CloudEvent`)
+	}
+	return ce.toEnvelope(item)
+}
+
+// EncodeCloudEventBinary returns headers and body of the event in CloudEvents binary mode
+func EncodeCloudEventBinary(ev Event, opts *CloudEventsOptions) (map[string]string, []byte, error) {
+	e, ok := ev.(*event)
+	if !ok {
+		return nil, nil, errors.Wrap(ErrNotCloudEvent, `unsupported event type`)
+	}
+	item, err := e.toEnvelope(e.mux.envelope())
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		ce      = newCloudEvent(item, opts)
+		prefix  = opts.headerPrefix()
+		headers = map[string]string{
+			prefix + "specversion": ce.SpecVersion,
+			prefix + "id":          ce.ID,
+			prefix + "source":      ce.Source,
+			prefix + "type":        ce.Type,
+			"content-type":         ce.DataContentType,
+		}
+	)
+	setHeader := func(name, value string) {
+		if value != "" && value != "0" && value != "false" {
+			headers[prefix+name] = value
+		}
+	}
+	if ce.Time != nil {
+		setHeader("time", ce.Time.Format(time.RFC3339Nano))
+	}
+	setHeader("apdone", ce.DoneTasks)
+	setHeader("apsent", strconv.Itoa(ce.SendCount))
+	setHeader("apretrans", strconv.Itoa(ce.RetranslateCount))
+	setHeader("apcomplete", strconv.FormatBool(ce.Complete))
+	setHeader("aperror", ce.Err)
+	setHeader("aperrorcode", ce.ErrCode)
+	setHeader("apcompress", ce.Compression)
+	setHeader("apkeyid", ce.KeyID)
+	setHeader("apref", ce.PayloadRef)
+	setHeader("apversion", strconv.Itoa(ce.PayloadVersion))
+	setHeader("apcorrelation", ce.CorrelationID)
+	setHeader("apreplyto", ce.ReplyTo)
+	return headers, item.Payload, nil
+}
+
+// DecodeCloudEventBinary returns event from headers and body of the message in CloudEvents binary mode
+func DecodeCloudEventBinary(headers map[string]string, body []byte, mux *TaskMux) (Event, error) {
+	ev := &event{}
+	return ev, ev.decodeCloudEventBinary(headers, body, mux)
+}
+
+func (ev *event) decodeCloudEventBinary(headers map[string]string, body []byte, mux *TaskMux) error {
+	var (
+		item   encodeEvent
+		header = cloudEventHeaders(headers)
+		ce     = cloudEvent{
+			SpecVersion:     header("specversion"),
+			ID:              header("id"),
+			Source:          header("source"),
+			Type:            header("type"),
+			DataContentType: header("content-type"),
+			DataBase64:      body,
+			DoneTasks:       header("apdone"),
+			Err:             header("aperror"),
+			ErrCode:         header("aperrorcode"),
+			Compression:     header("apcompress"),
+			KeyID:           header("apkeyid"),
+			PayloadRef:      header("apref"),
+			CorrelationID:   header("apcorrelation"),
+			ReplyTo:         header("apreplyto"),
+		}
+	)
+	if ce.SpecVersion == "" {
+		return ErrNotCloudEvent
+	}
+	ce.SendCount, _ = strconv.Atoi(header("apsent"))
+	ce.RetranslateCount, _ = strconv.Atoi(header("apretrans"))
+	ce.PayloadVersion, _ = strconv.Atoi(header("apversion"))
+	ce.Complete, _ = strconv.ParseBool(header("apcomplete"))
+	if tm, err := time.Parse(time.RFC3339Nano, header("time")); err == nil {
+		ce.Time = &tm
+	}
+	if err := ce.toEnvelope(&item); err != nil {
+		return err
+	}
+	return ev.fromEnvelope(&item, mux)
+}
+
+// cloudEventHeaders returns accessor of the CloudEvents attributes with any supported prefix
+func cloudEventHeaders(headers map[string]string) func(name string) string {
+	lower := make(map[string]string, len(headers))
+	for key, value := range headers {
+		lower[strings.ToLower(key)] = value
+	}
+	return func(name string) string {
+		if name == "content-type" {
+			return lower[name]
+		}
+		if val, ok := lower[CloudEventsHeaderPrefix+name]; ok {
+			return val
+		}
+		return lower[CloudEventsKafkaPrefix+name]
+	}
+}
+
+// isJSONData checks if the payload can be embedded into the CloudEvent as JSON
+func isJSONData(item *encodeEvent) bool {
+	return len(item.Payload) > 0 && item.Compression == "" && item.KeyID == "" &&
+		normalizeContentType(item.ContentType) == codec.JSON.ContentType() && json.Valid(item.Payload)
+}
+
+// normalizeContentType removes parameters and maps JSON-compatible types to the JSON codec
+func normalizeContentType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	if mediaType == "text/json" || strings.HasSuffix(mediaType, "+json") {
+		return codec.JSON.ContentType()
+	}
+	return mediaType
+}
+
+// withCloudEvents returns copy of options with the CloudEvents encoding
+func (opts *envelopeOptions) withCloudEvents(ce *CloudEventsOptions) *envelopeOptions {
+	var newOpts envelopeOptions
+	if opts != nil {
+		newOpts = *opts
+	}
+	newOpts.cloudEvents = ce
+	return &newOpts
+}
+
+// isCloudEvents returns true if events have to be encoded in CloudEvents structured mode
+func (opts *envelopeOptions) isCloudEvents() bool {
+	return opts != nil && opts.cloudEvents != nil
+}
+
+// isCloudEventsBinary returns true if events have to be encoded in CloudEvents binary mode
+func (opts *envelopeOptions) isCloudEventsBinary() bool {
+	return opts.isCloudEvents() && opts.cloudEvents.Mode == CloudEventsBinary
+}
+
+// publishEvent into the stream in CloudEvents binary mode if it's supported
+func publishEvent(ctx context.Context, pub Publisher, ev Event) error {
+	e, ok := ev.(*event)
+	if !ok {
+		return pub.Publish(ctx, ev)
+	}
+	e.markPublished()
+	if hpub, ok := pub.(HeaderPublisher); ok && e.mux.envelope().isCloudEventsBinary() {
+		headers, body, err := EncodeCloudEventBinary(e, e.mux.envelope().cloudEvents)
+		if err != nil {
+			return err
+		}
+		return hpub.PublishWithHeaders(ctx, headers, body)
+	}
+	return pub.Publish(ctx, ev)
+}
+
+type cloudEventsPublisher struct {
+	pub  Publisher
+	opts CloudEventsOptions
+}
+
+// PublisherWithCloudEvents wraps publisher with CloudEvents encoding of the events.
+// It overrides CloudEvents options of the mux.
+func PublisherWithCloudEvents(publisher Publisher, opts CloudEventsOptions) Publisher {
+	return &cloudEventsPublisher{pub: publisher, opts: opts}
+}
+
+// Publish events in CloudEvents format
+func (wr *cloudEventsPublisher) Publish(ctx context.Context, messages ...any) error {
+	hpub, isHeaderPublisher := wr.pub.(HeaderPublisher)
+	msgs := make([]any, 0, len(messages))
+	for _, msg := range messages {
+		if ev, ok := msg.(*event); ok {
+			if isHeaderPublisher && wr.opts.Mode == CloudEventsBinary {
+				headers, body, err := EncodeCloudEventBinary(ev, &wr.opts)
+				if err != nil {
+					return err
+				}
+				if err = hpub.PublishWithHeaders(ctx, headers, body); err != nil {
+					return err
+				}
+				continue
+			}
+			data, err := ev.encode(ev.mux.envelope().withCloudEvents(&wr.opts))
+			if err != nil {
+				return err
+			}
+			msg = json.RawMessage(data)
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return wr.pub.Publish(ctx, msgs...)
+}
+
+// Close the wrapped publisher if it's closable
+func (wr *cloudEventsPublisher) Close() error {
+	if closer, ok := wr.pub.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
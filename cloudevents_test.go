@@ -0,0 +1,97 @@
+package asyncp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testHeaderPublisher struct {
+	headers []map[string]string
+	bodies  [][]byte
+}
+
+func (p *testHeaderPublisher) Publish(ctx context.Context, messages ...any) error { return nil }
+
+func (p *testHeaderPublisher) PublishWithHeaders(ctx context.Context, headers map[string]string, body []byte) error {
+	p.headers = append(p.headers, headers)
+	p.bodies = append(p.bodies, body)
+	return nil
+}
+
+type testHeaderMessage struct {
+	message
+	headers map[string]string
+}
+
+func (m testHeaderMessage) Headers() map[string]string { return m.headers }
+
+func TestCloudEventsStructured(t *testing.T) {
+	mux := NewTaskMux(WithCloudEvents(CloudEventsOptions{Source: "/test"}))
+	ev := WithPayload("test", map[string]any{"text": "test"}).(*event)
+	ev.SetMux(mux)
+	ev.doneEvents = []string{"first", "second"}
+
+	data, err := ev.Encode()
+	assert.NoError(t, err)
+
+	var ce map[string]any
+	assert.NoError(t, json.Unmarshal(data, &ce))
+	assert.Equal(t, "1.0", ce["specversion"])
+	assert.Equal(t, "/test", ce["source"])
+	assert.Equal(t, "test", ce["type"])
+	assert.Equal(t, "first,second", ce["apdone"])
+	assert.Equal(t, map[string]any{"text": "test"}, ce["data"])
+
+	newEv, err := NewCloudEventsAllocator().Decode(message(data))
+	assert.NoError(t, err)
+	assert.Equal(t, ev.ID(), newEv.ID())
+	assert.Equal(t, ev.DoneTasks(), newEv.DoneTasks())
+
+	var res map[string]any
+	assert.NoError(t, newEv.Payload().Decode(&res))
+	assert.Equal(t, "test", res["text"])
+
+	_, err = NewCloudEventsAllocator().Decode(message(`{"name":"test"}`))
+	assert.ErrorIs(t, err, ErrNotCloudEvent)
+}
+
+func TestCloudEventsForeign(t *testing.T) {
+	data := `{"specversion":"1.0","id":"A234-1234","source":"/ext","type":"test",
+		"datacontenttype":"application/cloudevents+json","data":{"text":"test"}}`
+	ev, err := newDefaultEventAllocator().Decode(message(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "test", ev.Name())
+	assert.NotEqual(t, "00000000-0000-0000-0000-000000000000", ev.ID().String())
+
+	var res map[string]any
+	assert.NoError(t, ev.Payload().Decode(&res))
+	assert.Equal(t, "test", res["text"])
+}
+
+func TestCloudEventsBinary(t *testing.T) {
+	var (
+		pub  = &testHeaderPublisher{}
+		opts = CloudEventsOptions{Source: "/test", Mode: CloudEventsBinary, HeaderPrefix: CloudEventsKafkaPrefix}
+		ev   = WithPayload("test", map[string]any{"text": "test"})
+	)
+	ev.After(WithPayload("prev", nil))
+	assert.NoError(t, PublisherWithCloudEvents(pub, opts).Publish(context.Background(), ev))
+	if assert.Len(t, pub.headers, 1) {
+		assert.Equal(t, "test", pub.headers[0]["ce_type"])
+		assert.Equal(t, "prev", pub.headers[0]["ce_apdone"])
+		assert.JSONEq(t, `{"text":"test"}`, string(pub.bodies[0]))
+	}
+
+	newEv, err := NewCloudEventsAllocator().Decode(testHeaderMessage{
+		message: message(pub.bodies[0]),
+		headers: pub.headers[0],
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ev.ID(), newEv.ID())
+	assert.Equal(t, []string{"prev"}, newEv.DoneTasks())
+	sent, _ := newEv.Counters()
+	assert.Equal(t, 1, sent)
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"log"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/multierr"
 
+	"github.com/demdxx/asyncp/v2/graph"
 	"github.com/demdxx/asyncp/v2/monitor"
 )
 
@@ -55,6 +57,14 @@ func ClusterWithStores(stores ...monitor.MetricUpdater) ClusterOption {
 	}
 }
 
+// ClusterWithSchemaConflictHandler reports incompatible producer and consumer
+// payload schemas found during the cluster synchronisation
+func ClusterWithSchemaConflictHandler(h func(conflicts []monitor.SchemaConflict)) ClusterOption {
+	return func(cluster *Cluster) {
+		cluster.schemaConflictHandler = h
+	}
+}
+
 // ClusterExt extends functionality of mux
 type ClusterExt interface {
 	RegisterApplication(ctx context.Context, mux *TaskMux) error
@@ -78,6 +88,7 @@ type Cluster struct {
 	// interval of cluster infor updating
 	syncInterval time.Duration
 	tickInterval *time.Ticker
+	syncStop     chan struct{}
 
 	infoReader    monitor.ClusterInfoReader
 	clusterStores []monitor.MetricUpdater
@@ -85,6 +96,9 @@ type Cluster struct {
 	taskMap map[string][]string
 	appInfo *monitor.ApplicationInfo
 
+	schemaConflicts       []monitor.SchemaConflict
+	schemaConflictHandler func(conflicts []monitor.SchemaConflict)
+
 	mux *TaskMux
 }
 
@@ -117,12 +131,15 @@ func (cluster *Cluster) RegisterApplication(ctx context.Context, mux *TaskMux) (
 	if cluster == nil {
 		return nil
 	}
+	cluster.mx.Lock()
 	cluster.mux = mux
+	cluster.mx.Unlock()
 	appInfo := &monitor.ApplicationInfo{
 		Name:     cluster.appName,
 		Host:     cluster.hostIP,
 		Hostname: cluster.hostname,
 		Tasks:    mux.TaskMap(),
+		Schemas:  mux.TaskSchemas(),
 	}
 	for _, up := range cluster.clusterStores {
 		if regErr := up.RegisterApplication(appInfo); regErr != nil {
@@ -185,6 +202,20 @@ func (cluster *Cluster) ExecEvent(failover bool, event Event, execTime time.Dura
 	return resErr
 }
 
+// EncodeEvent handler after event payload has been encoded
+func (cluster *Cluster) EncodeEvent(event Event, rawSize, encodedSize int) error {
+	if cluster == nil {
+		return nil
+	}
+	var resErr error
+	for _, up := range cluster.clusterStores {
+		if pup, ok := up.(monitor.PayloadMetricUpdater); ok {
+			resErr = multierr.Append(resErr, pup.EncodePayload(event, rawSize, encodedSize))
+		}
+	}
+	return resErr
+}
+
 // TargetEventsAfter returns list of events to execute after the current event
 func (cluster *Cluster) TargetEventsAfter(eventName string) []string {
 	cluster.mx.RLock()
@@ -259,14 +290,17 @@ func (cluster *Cluster) RunSync(ctx context.Context) {
 	}
 
 	cluster.StopSync()
-	defer cluster.StopSync()
 
 	cluster.mx.Lock()
 	cluster.tickInterval = time.NewTicker(cluster.syncInterval)
-	tickChan := cluster.tickInterval.C
+	cluster.syncStop = make(chan struct{})
+	tickChan, stop := cluster.tickInterval.C, cluster.syncStop
 	cluster.mx.Unlock()
+	defer cluster.stopSync(stop)
 	for {
 		select {
+		case <-stop:
+			return
 		case _, ok := <-tickChan:
 			if !ok {
 				return
@@ -282,12 +316,24 @@ func (cluster *Cluster) RunSync(ctx context.Context) {
 
 // StopSync interval processing
 func (cluster *Cluster) StopSync() {
+	cluster.stopSync(nil)
+}
+
+// stopSync of the specific run or the current one if stop is nil
+func (cluster *Cluster) stopSync(stop chan struct{}) {
 	cluster.mx.Lock()
 	defer cluster.mx.Unlock()
+	if stop != nil && stop != cluster.syncStop {
+		return
+	}
 	if cluster.tickInterval != nil {
 		cluster.tickInterval.Stop()
 		cluster.tickInterval = nil
 	}
+	if cluster.syncStop != nil {
+		close(cluster.syncStop)
+		cluster.syncStop = nil
+	}
 }
 
 // SyncInfo of the cluster
@@ -299,18 +345,109 @@ func (cluster *Cluster) SyncInfo() error {
 	if err != nil {
 		return err
 	}
-	cluster.appInfo = &monitor.ApplicationInfo{}
+	// Merge the info locally and replace the current one under the lock
+	info := &monitor.ApplicationInfo{}
 	if appInfo == nil {
+		cluster.mx.Lock()
+		cluster.appInfo = info
+		cluster.mx.Unlock()
 		return ErrNoSyncInformation
 	}
-	cluster.appInfo.Merge(appInfo)
+	info.Merge(appInfo)
+	var taskMap map[string][]string
 	if appInfo.Tasks != nil {
-		cluster.mx.Lock()
-		defer cluster.mx.Unlock()
-		cluster.taskMap = map[string][]string{}
-		for k, v := range cluster.appInfo.Tasks {
-			cluster.taskMap[k] = append([]string{}, v...)
+		taskMap = map[string][]string{}
+		for k, v := range info.Tasks {
+			taskMap[k] = append([]string{}, v...)
 		}
 	}
+	cluster.mx.Lock()
+	cluster.appInfo = info
+	if taskMap != nil {
+		cluster.taskMap = taskMap
+	}
+	cluster.mx.Unlock()
+	cluster.syncSchemaConflicts(info)
 	return nil
 }
+
+// InfoReader returns the reader of the cluster info or nil
+func (cluster *Cluster) InfoReader() monitor.ClusterInfoReader {
+	return cluster.infoReader
+}
+
+// TaskGraph returns merged graph of all applications of the cluster
+func (cluster *Cluster) TaskGraph() *graph.Graph {
+	cluster.mx.RLock()
+	defer cluster.mx.RUnlock()
+	return graph.FromApplication(cluster.appInfo)
+}
+
+// ValidateGraph checks the merged task graph of the cluster including the registered application
+func (cluster *Cluster) ValidateGraph() graph.Diagnostics {
+	if cluster == nil {
+		return nil
+	}
+	cluster.mx.RLock()
+	mux := cluster.mux
+	cluster.mx.RUnlock()
+	return cluster.validateGraph(mux)
+}
+
+// validateGraph checks the merged task graph of the cluster with the tasks of the mux,
+// the mux can be not registered in the cluster yet
+func (cluster *Cluster) validateGraph(mux *TaskMux) graph.Diagnostics {
+	if cluster == nil || cluster.infoReader == nil {
+		return nil
+	}
+	cluster.mx.RLock()
+	synced := cluster.appInfo != nil
+	cluster.mx.RUnlock()
+	if !synced {
+		if err := cluster.SyncInfo(); err != nil {
+			log.Printf("validate cluster graph: %s", err.Error())
+		}
+	}
+	info := &monitor.ApplicationInfo{}
+	cluster.mx.RLock()
+	if cluster.appInfo != nil {
+		info.Merge(cluster.appInfo)
+	}
+	cluster.mx.RUnlock()
+	if mux != nil {
+		info.Merge(&monitor.ApplicationInfo{
+			Name:    cluster.appName,
+			Host:    cluster.hostIP,
+			Tasks:   mux.TaskMap(),
+			Schemas: mux.TaskSchemas(),
+		})
+	}
+	return graph.FromApplication(info).Validate(true)
+}
+
+// SchemaConflicts returns incompatible producer and consumer pairs of the cluster
+func (cluster *Cluster) SchemaConflicts() []monitor.SchemaConflict {
+	cluster.mx.RLock()
+	defer cluster.mx.RUnlock()
+	return cluster.schemaConflicts
+}
+
+func (cluster *Cluster) syncSchemaConflicts(info *monitor.ApplicationInfo) {
+	conflicts := monitor.SchemaConflicts(info)
+	cluster.mx.Lock()
+	changed := !slices.EqualFunc(cluster.schemaConflicts, conflicts, func(a, b monitor.SchemaConflict) bool {
+		return a.String() == b.String()
+	})
+	cluster.schemaConflicts = conflicts
+	cluster.mx.Unlock()
+	if !changed || len(conflicts) == 0 {
+		return
+	}
+	if cluster.schemaConflictHandler != nil {
+		cluster.schemaConflictHandler(conflicts)
+		return
+	}
+	for _, conflict := range conflicts {
+		log.Printf("schema conflict %s", conflict.String())
+	}
+}
@@ -3,8 +3,11 @@ package asyncp
 import (
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/demdxx/asyncp/v2/graph"
 	"github.com/demdxx/asyncp/v2/monitor"
+	"github.com/demdxx/asyncp/v2/schema"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,3 +31,69 @@ func TestClusterAllTasks(t *testing.T) {
 		t.Error("Expected", expectedChains, "Actual", chains)
 	}
 }
+
+type testClusterInfoReader struct{ appInfo monitor.ApplicationInfo }
+
+func (r *testClusterInfoReader) ApplicationInfo() (*monitor.ApplicationInfo, error) {
+	return &r.appInfo, nil
+}
+func (r *testClusterInfoReader) TaskInfo(string) (*monitor.TaskInfo, error)     { return nil, nil }
+func (r *testClusterInfoReader) TaskInfoByID(string) (*monitor.TaskInfo, error) { return nil, nil }
+func (r *testClusterInfoReader) ListOfNodes() (map[string][]string, int, error) { return nil, 0, nil }
+func (r *testClusterInfoReader) EventStatus(string) (*monitor.EventStatus, error)  { return nil, nil }
+
+func TestClusterSchemaConflicts(t *testing.T) {
+	type rssItem struct {
+		Link string `json:"link,omitempty"`
+	}
+	type video struct {
+		Link string `json:"link" jsonschema:"required"`
+	}
+	var (
+		conflicts []monitor.SchemaConflict
+		reader    = &testClusterInfoReader{}
+		producer  = NewTaskMux()
+		consumer  = NewTaskMux()
+	)
+	producer.Handle("rss", func(s string) {}, WithOutputSchema(schema.For[rssItem]()))
+	consumer.Handle("rss>videoExtraction", func(v video) {}, WithInputValidation())
+	reader.appInfo.Merge(&monitor.ApplicationInfo{Name: "a", Tasks: producer.TaskMap(), Schemas: producer.TaskSchemas()})
+	reader.appInfo.Merge(&monitor.ApplicationInfo{Name: "b", Tasks: consumer.TaskMap(), Schemas: consumer.TaskSchemas()})
+
+	cluster := NewCluster("b", ClusterWithReader(reader),
+		ClusterWithSchemaConflictHandler(func(c []monitor.SchemaConflict) { conflicts = c }))
+	assert.NoError(t, cluster.SyncInfo())
+	if assert.Len(t, conflicts, 1) {
+		assert.Equal(t, "a", conflicts[0].ProducerApp)
+		assert.Equal(t, "videoExtraction", conflicts[0].Consumer)
+		assert.Equal(t, "b", conflicts[0].ConsumerApp)
+	}
+	assert.Equal(t, conflicts, cluster.SchemaConflicts())
+}
+
+func TestClusterValidateGraph(t *testing.T) {
+	var (
+		reader  = &testClusterInfoReader{}
+		appA    = NewTaskMux()
+		cluster = NewCluster("b", ClusterWithReader(reader))
+		appB    = NewTaskMux(WithClusterObject(cluster), WithControl(&testControlStore{}, time.Hour))
+	)
+	appA.Handle("rss", func(s string) string { return s }).Then(func(s string) {})
+	appB.Handle("rss", func(s string) string { return s }).Then(func(s string) {})
+	appB.Handle("video>thumbs", func(s string) {})
+	reader.appInfo.Merge(&monitor.ApplicationInfo{Name: "a", Tasks: appA.TaskMap()})
+
+	assert.Error(t, appB.FinishInit())
+	assert.False(t, appB.inited.Load())
+	assert.Nil(t, cluster.mux, "invalid graph must not be registered")
+	assert.Nil(t, appB.controlWatcher.cancel, "controls must not be watched")
+
+	diagnostics := appB.Validate()
+	assert.True(t, diagnostics.HasErrors())
+	if assert.Len(t, diagnostics, 2) {
+		assert.Equal(t, graph.DiagnosticDanglingParent, diagnostics[0].Kind)
+		assert.Equal(t, graph.DiagnosticDuplicateAnonymous, diagnostics[1].Kind)
+		assert.Equal(t, []string{"rss.1"}, diagnostics[1].Tasks)
+		assert.True(t, diagnostics[1].Cluster)
+	}
+}
@@ -10,10 +10,12 @@ import (
 	"time"
 
 	"github.com/demdxx/gocast/v2"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	cli "github.com/urfave/cli/v2"
 
 	"github.com/demdxx/asyncp/v2/cmd/apmonitor/tabledata"
+	"github.com/demdxx/asyncp/v2/graph"
 	"github.com/demdxx/asyncp/v2/monitor"
 	"github.com/demdxx/asyncp/v2/monitor/driver/redis"
 	"github.com/demdxx/asyncp/v2/monitor/kvstorage"
@@ -47,6 +49,38 @@ func main() {
 			},
 		},
 		Action: runMonitor,
+		Commands: []*cli.Command{
+			{
+				Name:   "schemas",
+				Usage:  "check payload schemas of linked producer and consumer tasks",
+				Action: runSchemas,
+			},
+			{
+				Name:      "control",
+				Usage:     "pause, drain or resume the task or the whole nodes (*) of the applications",
+				ArgsUsage: "pause|drain|resume task",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "host",
+						Usage: "host IP or hostname of the node, all nodes by default",
+					},
+				},
+				Action: runControl,
+			},
+			{
+				Name:  "graph",
+				Usage: "print the task graph of the applications",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "output format: dot, mermaid, json",
+						Value:   "dot",
+					},
+				},
+				Action: runGraph,
+			},
+		},
 	}
 	err := app.Run(os.Args)
 	if err != nil {
@@ -64,16 +98,43 @@ func runMonitor(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	controls, err := connectControl(storageURL)
+	if err != nil {
+		return err
+	}
 	ticker := time.NewTicker(interval)
 	app := tview.NewApplication()
 
 	tableData := tabledata.NewTableData(nil)
-	tableData.SetHeaders([]string{"task", "min", "max", "avg", "success", "skip", "error", "total"})
+	tableData.SetHeaders([]string{"task", "min", "max", "avg", "success", "skip", "invalid", "error", "total"})
 	table := tview.NewTable().
 		SetBorders(false).
 		SetSelectable(true, false).
 		SetContent(tableData)
 
+	// p/d/r pause, drain or resume the selected task, P/D/R the whole nodes
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		var (
+			action   = strings.ToLower(string(event.Rune()))
+			taskName = monitor.AllTasks
+		)
+		if action != "p" && action != "d" && action != "r" {
+			return event
+		}
+		if event.Rune() >= 'a' {
+			row, _ := table.GetSelection()
+			item := tableData.Row(row)
+			if len(item) == 0 {
+				return nil
+			}
+			taskName = strings.Fields(item[0])[0]
+		}
+		if err := sendControl(controls, applicationNames(applicationName), "", action, taskName); err != nil {
+			log.Print(err)
+		}
+		return nil
+	})
+
 	go func() {
 		iter := 0
 		for {
@@ -83,7 +144,7 @@ func runMonitor(c *cli.Context) error {
 				return
 			case <-ticker.C:
 				iter++
-				updateInfo(iter, app, tableData, storage)
+				updateInfo(iter, app, tableData, storage, controls)
 			}
 		}
 	}()
@@ -91,6 +152,92 @@ func runMonitor(c *cli.Context) error {
 	return app.SetRoot(table, true).EnableMouse(true).Run()
 }
 
+func runSchemas(c *cli.Context) error {
+	storage, err := connectStorage(c.String("storage"), c.String("app"))
+	if err != nil {
+		return err
+	}
+	appInfo, err := storage.ApplicationInfo()
+	if err != nil {
+		return err
+	}
+	conflicts := monitor.SchemaConflicts(appInfo)
+	for _, conflict := range conflicts {
+		fmt.Printf("%s:%s > %s:%s\n", conflict.ProducerApp, conflict.Producer, conflict.ConsumerApp, conflict.Consumer)
+		for _, problem := range conflict.Problems {
+			fmt.Println("  " + problem)
+		}
+	}
+	if len(conflicts) > 0 {
+		return cli.Exit(fmt.Sprintf("%d schema conflicts found", len(conflicts)), 1)
+	}
+	fmt.Println("no schema conflicts")
+	return nil
+}
+
+func runControl(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.Exit("action and task are required", 1)
+	}
+	controls, err := connectControl(c.String("storage"))
+	if err != nil {
+		return err
+	}
+	return sendControl(controls, applicationNames(c.String("app")),
+		c.String("host"), c.Args().Get(0), c.Args().Get(1))
+}
+
+// sendControl to the nodes of the applications, resume deletes the control state
+func sendControl(controls monitor.ControlStore, apps []string, host, action, taskName string) error {
+	for _, appName := range apps {
+		ctrl := &monitor.Control{App: appName, Host: host, Task: taskName, CreatedAt: time.Now()}
+		var err error
+		switch action {
+		case "p", "pause":
+			ctrl.Action = monitor.ControlPause
+			err = controls.SetControl(ctrl)
+		case "d", "drain":
+			ctrl.Action = monitor.ControlDrain
+			err = controls.SetControl(ctrl)
+		case "r", "resume":
+			err = controls.DeleteControl(ctrl)
+		default:
+			return fmt.Errorf("unsupported control action: %s", action)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runGraph(c *cli.Context) error {
+	storage, err := connectStorage(c.String("storage"), c.String("app"))
+	if err != nil {
+		return err
+	}
+	appInfo, err := storage.ApplicationInfo()
+	if err != nil {
+		return err
+	}
+	g := graph.FromApplication(appInfo)
+	switch c.String("format") {
+	case "dot":
+		fmt.Print(g.DOT())
+	case "mermaid":
+		fmt.Print(g.Mermaid())
+	case "json":
+		data, err := g.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		return fmt.Errorf("unsupported graph format: %s", c.String("format"))
+	}
+	return nil
+}
+
 func connectStorage(connectURL, applicationName string) (monitor.ClusterInfoReader, error) {
 	parsedURL, err := url.Parse(connectURL)
 	if err != nil {
@@ -103,15 +250,46 @@ func connectStorage(connectURL, applicationName string) (monitor.ClusterInfoRead
 			return nil, err
 		}
 		return kvstorage.NewClusterInfoReader(kvaccessor,
-			strings.Split(applicationName, ",")...), nil
+			applicationNames(applicationName)...), nil
 	default:
 		return nil, fmt.Errorf("unsupported monitor storage: %s", parsedURL.Scheme)
 	}
 }
 
-func updateInfo(iter int, app *tview.Application, tableData *tabledata.TableData, info monitor.ClusterInfoReader) {
+func connectControl(connectURL string) (monitor.ControlStore, error) {
+	parsedURL, err := url.Parse(connectURL)
+	if err != nil {
+		return nil, err
+	}
+	switch parsedURL.Scheme {
+	case "redis":
+		kvaccessor, err := redis.New(connectURL)
+		if err != nil {
+			return nil, err
+		}
+		return kvstorage.NewControlStore(kvaccessor), nil
+	default:
+		return nil, fmt.Errorf("unsupported control storage: %s", parsedURL.Scheme)
+	}
+}
+
+func applicationNames(names string) []string {
+	return strings.Split(names, ",")
+}
+
+func updateInfo(iter int, app *tview.Application, tableData *tabledata.TableData, info monitor.ClusterInfoReader, controls monitor.ControlStore) {
 	appInfo, _ := info.ApplicationInfo()
+	actions := map[string]monitor.ControlAction{}
+	if list, err := controls.Controls(); err == nil {
+		for _, ctrl := range list {
+			actions[ctrl.Task] = ctrl.Action
+		}
+	}
 	nodeCount := 0
+	conflicts := map[string]bool{}
+	for _, conflict := range monitor.SchemaConflicts(appInfo) {
+		conflicts[conflict.Consumer] = true
+	}
 
 	data := [][]string{}
 
@@ -125,15 +303,23 @@ func updateInfo(iter int, app *tview.Application, tableData *tabledata.TableData
 				continue
 			}
 			taskInfo, _ := info.TaskInfo(taskName)
-			item := []string{taskName, "?", "?", "?", "?", "?", "?", "?"}
+			item := []string{taskName, "?", "?", "?", "?", "?", "?", "?", "?"}
+			if conflicts[taskName] {
+				// Payload schema of the producer doesn't match the task input
+				item[0] = taskName + " (!)"
+			}
+			if action := gocast.Or(actions[taskName], actions[monitor.AllTasks]); action != "" {
+				item[0] += " [" + string(action) + "]"
+			}
 			if taskInfo != nil {
 				item[1] = taskInfo.MinExecTime.String()
 				item[2] = taskInfo.MaxExecTime.String()
 				item[3] = taskInfo.AvgExecTime.String()
 				item[4] = gocast.Str(taskInfo.SuccessCount)
 				item[5] = gocast.Str(taskInfo.SkipCount)
-				item[6] = gocast.Str(taskInfo.ErrorCount)
-				item[7] = gocast.Str(taskInfo.TotalCount)
+				item[6] = gocast.Str(taskInfo.ValidationCount)
+				item[7] = gocast.Str(taskInfo.ErrorCount)
+				item[8] = gocast.Str(taskInfo.TotalCount)
 			}
 			data = append(data, item)
 		}
@@ -144,7 +330,10 @@ func updateInfo(iter int, app *tview.Application, tableData *tabledata.TableData
 	}
 
 	tableData.SetData(data)
-	tableData.SetFooter([]string{"", "", "", "", "", "",
+	tableData.SetFooter([]string{
+		gocast.IfThen(len(conflicts) > 0, "Schema conflicts:", ""),
+		gocast.IfThen(len(conflicts) > 0, gocast.Str(len(conflicts)), ""),
+		"", "", "", "", "",
 		gocast.IfThen(iter%2 == 0, "Nodes ", "Nodes:"),
 		gocast.Str(nodeCount)})
 
@@ -86,6 +86,16 @@ func (d *TableData) GetCell(row, column int) *tview.TableCell {
 		SetAttributes(columnAttrByName(columnName))
 }
 
+// Row returns data of the table row or nil for the header and footer
+func (d *TableData) Row(row int) []string {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	if row < 1 || row > len(d.data) {
+		return nil
+	}
+	return d.data[row-1]
+}
+
 func (d *TableData) GetRowCount() int {
 	d.mx.RLock()
 	defer d.mx.RUnlock()
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// errEncryptedRename in case of renaming of the event with the payload bound to its name
+var errEncryptedRename = errors.New("encrypted event can't be renamed, the payload is bound to the event name")
+
+// replayEvent keeps the original message and the fields used by filters.
+// The message is published as is, so encrypted and compressed payloads
+// are replayed without the keys.
+type replayEvent struct {
+	ID        string
+	Name      string
+	Err       string
+	CreatedAt time.Time
+	Data      json.RawMessage
+
+	// cloudEvent message format uses other field names
+	cloudEvent bool
+
+	// ack removes the message from the source stream after the publishing
+	ack func() error
+}
+
+// parseEvent from the envelope or the structured CloudEvent message
+func parseEvent(data []byte, tm time.Time) (*replayEvent, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	ev := &replayEvent{Data: data, CreatedAt: tm}
+	nameField, errField, timeField := "name", "error", "created_at"
+	if _, ok := fields["specversion"]; ok {
+		ev.cloudEvent = true
+		nameField, errField, timeField = "type", "aperror", "time"
+	}
+	_ = json.Unmarshal(fields["id"], &ev.ID)
+	_ = json.Unmarshal(fields[nameField], &ev.Name)
+	_ = json.Unmarshal(fields[errField], &ev.Err)
+	if ev.CreatedAt.IsZero() {
+		_ = json.Unmarshal(fields[timeField], &ev.CreatedAt)
+	}
+	return ev, nil
+}
+
+// rename the event in the message, encrypted events can't be renamed without the keys
+func (ev *replayEvent) rename(name string) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(ev.Data, &fields); err != nil {
+		return err
+	}
+	field, keyField := "name", "key_id"
+	if ev.cloudEvent {
+		field, keyField = "type", "apkeyid"
+	}
+	var keyID string
+	_ = json.Unmarshal(fields[keyField], &keyID)
+	if keyID != "" {
+		return fmt.Errorf("%w: %s %s", errEncryptedRename, ev.Name, ev.ID)
+	}
+	fields[field], _ = json.Marshal(name)
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	ev.Name, ev.Data = name, data
+	return nil
+}
+
+type filter struct {
+	names  []string
+	from   time.Time
+	to     time.Time
+	errStr string
+	rootID string
+}
+
+// match the event with all defined conditions, names are glob patterns
+func (f *filter) match(ev *replayEvent) bool {
+	if f.rootID != "" && ev.ID != f.rootID {
+		return false
+	}
+	if f.errStr != "" && !strings.Contains(ev.Err, f.errStr) {
+		return false
+	}
+	if !f.from.IsZero() && ev.CreatedAt.Before(f.from) {
+		return false
+	}
+	if !f.to.IsZero() && !ev.CreatedAt.Before(f.to) {
+		return false
+	}
+	if len(f.names) == 0 {
+		return true
+	}
+	for _, pattern := range f.names {
+		if ok, _ := path.Match(pattern, ev.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTime in RFC3339 format or the duration before now like "2h"
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseRenames of the "old=new" pairs
+func parseRenames(list []string) map[string]string {
+	renames := make(map[string]string, len(list))
+	for _, item := range list {
+		if oldName, newName, ok := strings.Cut(item, "="); ok {
+			renames[oldName] = newName
+		}
+	}
+	return renames
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayFilter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	from, err := parseTime("2h", now)
+	if !assert.NoError(t, err) {
+		return
+	}
+	flt := &filter{names: []string{"video*"}, from: from, errStr: "timeout"}
+
+	ev, err := parseEvent([]byte(`{"id":"1","name":"video.1","error":"read timeout","created_at":"2026-01-01T11:00:00Z"}`), time.Time{})
+	if assert.NoError(t, err) {
+		assert.True(t, flt.match(ev))
+	}
+	ev, err = parseEvent([]byte(`{"id":"2","name":"video","error":"read timeout","created_at":"2026-01-01T09:00:00Z"}`), time.Time{})
+	if assert.NoError(t, err) {
+		assert.False(t, flt.match(ev), "event is older than the time range")
+	}
+	ev, err = parseEvent([]byte(`{"specversion":"1.0","id":"3","type":"rss","aperror":"timeout","time":"2026-01-01T11:30:00Z"}`), time.Time{})
+	if assert.NoError(t, err) {
+		assert.False(t, flt.match(ev), "event name doesn't match")
+		assert.NoError(t, ev.rename("video"))
+		assert.True(t, flt.match(ev))
+		assert.Contains(t, string(ev.Data), `"type":"video"`)
+	}
+}
+
+func TestReplayRenameEncrypted(t *testing.T) {
+	ev, err := parseEvent([]byte(`{"id":"1","name":"video","key_id":"k1","payload":"c2VjcmV0"}`), time.Time{})
+	if assert.NoError(t, err) {
+		assert.ErrorIs(t, ev.rename("video.v2"), errEncryptedRename)
+		assert.Equal(t, "video", ev.Name)
+	}
+	ev, err = parseEvent([]byte(`{"specversion":"1.0","id":"2","type":"video","apkeyid":"k1"}`), time.Time{})
+	if assert.NoError(t, err) {
+		assert.ErrorIs(t, ev.rename("video.v2"), errEncryptedRename)
+	}
+
+	// Events without the key are renamed and replayed
+	var published []string
+	events := func(yield func(*replayEvent, error) bool) {
+		for _, data := range []string{`{"id":"3","name":"video","payload":"e30="}`, `{"id":"4","name":"video","key_id":"k1"}`} {
+			if !yield(parseEvent([]byte(data), time.Time{})) {
+				return
+			}
+		}
+	}
+	count, _, err := replayEvents(context.Background(), events, &filter{}, map[string]string{"video": "video.v2"}, nil,
+		func(ev *replayEvent) error { published = append(published, ev.Name); return nil })
+	assert.ErrorIs(t, err, errEncryptedRename)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"video.v2"}, published)
+}
+
+func TestParseLine(t *testing.T) {
+	ev, err := parseLine([]byte(`{"time":"2026-01-01T11:00:00Z","dir":"in","id":"1","name":"rss","error":"boom","event":{"id":"1","name":"rss"}}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "rss", ev.Name)
+		assert.Equal(t, "boom", ev.Err)
+		assert.Equal(t, `{"id":"1","name":"rss"}`, string(ev.Data))
+	}
+	assert.Equal(t, map[string]string{"rss": "rss2"}, parseRenames([]string{"rss=rss2", "invalid"}))
+}
+
+func TestReplayEventsAck(t *testing.T) {
+	var (
+		acked  []string
+		flt    = &filter{names: []string{"video*"}}
+		events = func(yield func(*replayEvent, error) bool) {
+			for _, data := range []string{`{"id":"1","name":"video"}`, `{"id":"2","name":"rss"}`, `{"id":"3","name":"video"}`} {
+				ev, err := parseEvent([]byte(data), time.Time{})
+				if err == nil {
+					ev.ack = func() error { acked = append(acked, ev.ID); return nil }
+				}
+				if !yield(ev, err) {
+					return
+				}
+			}
+		}
+		dryRun = func(ev *replayEvent) error { return nil }
+	)
+	count, _, err := replayEvents(context.Background(), events, flt, nil, nil, dryRun)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Empty(t, acked, "dry run doesn't ack messages")
+
+	publish := func(ev *replayEvent) error {
+		if ev.ID == "3" {
+			return errors.New("publish failed")
+		}
+		return ev.ack()
+	}
+	_, _, err = replayEvents(context.Background(), events, flt, nil, nil, publish)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"1"}, acked, "not matched and failed events are not acked")
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/demdxx/asyncp/v2/journal"
+	"github.com/demdxx/asyncp/v2/streams"
+)
+
+func main() {
+	app := &cli.App{
+		Name:  "apreplay",
+		Usage: "replay journaled, dead-lettered or dumped events to the stream",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "journal",
+				Aliases: []string{"j"},
+				Usage:   "journal directory to read events from",
+				EnvVars: []string{"APREPLAY_JOURNAL"},
+			},
+			&cli.StringFlag{
+				Name:    "direction",
+				Usage:   "direction of the journal records: in, out or empty for all",
+				EnvVars: []string{"APREPLAY_DIRECTION"},
+				Value:   string(journal.DirectionIn),
+			},
+			&cli.StringFlag{
+				Name:    "stream",
+				Aliases: []string{"s"},
+				Usage:   "DLQ stream connect kafka://hostname:port/topic?group=replay",
+				EnvVars: []string{"APREPLAY_STREAM"},
+			},
+			&cli.DurationFlag{
+				Name:    "idle",
+				Usage:   "stop reading of the stream after the idle interval",
+				EnvVars: []string{"APREPLAY_IDLE"},
+				Value:   10 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "JSONL dump of the events or journal records",
+				EnvVars: []string{"APREPLAY_FILE"},
+			},
+			&cli.StringFlag{
+				Name:    "target",
+				Aliases: []string{"t"},
+				Usage:   "publisher connect nats://hostname:port/subject",
+				EnvVars: []string{"APREPLAY_TARGET"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "name",
+				Aliases: []string{"n"},
+				Usage:   "event name patterns like 'video*'",
+			},
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "events created after the time in RFC3339 or the duration before now like 2h",
+			},
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "events created before the time in RFC3339 or the duration before now",
+			},
+			&cli.StringFlag{
+				Name:  "error",
+				Usage: "events with the error text",
+			},
+			&cli.StringFlag{
+				Name:  "root",
+				Usage: "events of the chain with the root event ID",
+			},
+			&cli.StringSliceFlag{
+				Name:    "rename",
+				Aliases: []string{"r"},
+				Usage:   "rewrite event names by 'old=new', encrypted events can't be renamed",
+			},
+			&cli.Float64Flag{
+				Name:    "rate",
+				Usage:   "max events per second, 0 is unlimited",
+				EnvVars: []string{"APREPLAY_RATE"},
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print events without publishing",
+			},
+		},
+		Action: runReplay,
+	}
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runReplay(c *cli.Context) (err error) {
+	ctx, cancel := signal.NotifyContext(c.Context, os.Interrupt)
+	defer cancel()
+
+	flt, err := newFilter(c, time.Now())
+	if err != nil {
+		return err
+	}
+	events, err := openSource(ctx, c, flt)
+	if err != nil {
+		return err
+	}
+
+	dryRun := c.Bool("dry-run")
+	publish := func(ev *replayEvent) error {
+		fmt.Printf("%s %s %s\n", ev.CreatedAt.Format(time.RFC3339), ev.ID, ev.Name)
+		return nil
+	}
+	if !dryRun {
+		if c.String("target") == "" {
+			return cli.Exit("target publisher is required", 1)
+		}
+		pub, pubErr := streams.PublisherFromURL(ctx, c.String("target"))
+		if pubErr != nil {
+			return pubErr
+		}
+		if closer, ok := pub.(io.Closer); ok {
+			// Flush the events before the exit
+			defer func() {
+				if closeErr := closer.Close(); closeErr != nil {
+					log.Printf("close target: %s", closeErr)
+					if err == nil {
+						err = closeErr
+					}
+				}
+			}()
+		}
+		publish = func(ev *replayEvent) error {
+			if err := pub.Publish(ctx, ev.Data); err != nil {
+				return err
+			}
+			if ev.ack != nil {
+				return ev.ack()
+			}
+			return nil
+		}
+	}
+
+	var limiter <-chan time.Time
+	if rate := c.Float64("rate"); rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	count, failed, err := replayEvents(ctx, events, flt, parseRenames(c.StringSlice("rename")), limiter, publish)
+	if dryRun {
+		log.Printf("%d events matched, %d skipped", count, failed)
+	} else {
+		log.Printf("%d events replayed, %d skipped", count, failed)
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// replayEvents publishes events matched by the filter, messages of the stream source
+// are acked by the publish function only after the successful publishing
+func replayEvents(ctx context.Context, events iter.Seq2[*replayEvent, error], flt *filter,
+	renames map[string]string, limiter <-chan time.Time, publish func(ev *replayEvent) error) (count, failed int, err error) {
+	for ev, err := range events {
+		if err != nil {
+			if !isParseError(err) {
+				return count, failed, err
+			}
+			failed++
+			log.Printf("skip event: %s", err)
+			continue
+		}
+		if !flt.match(ev) {
+			continue
+		}
+		if newName, ok := renames[ev.Name]; ok {
+			if err := ev.rename(newName); err != nil {
+				return count, failed, err
+			}
+		}
+		if limiter != nil {
+			select {
+			case <-ctx.Done():
+				return count, failed, ctx.Err()
+			case <-limiter:
+			}
+		}
+		if err := publish(ev); err != nil {
+			return count, failed, err
+		}
+		count++
+	}
+	return count, failed, nil
+}
+
+func newFilter(c *cli.Context, now time.Time) (*filter, error) {
+	from, err := parseTime(c.String("from"), now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from time: %w", err)
+	}
+	to, err := parseTime(c.String("to"), now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to time: %w", err)
+	}
+	return &filter{
+		names:  c.StringSlice("name"),
+		from:   from,
+		to:     to,
+		errStr: c.String("error"),
+		rootID: c.String("root"),
+	}, nil
+}
+
+func openSource(ctx context.Context, c *cli.Context, flt *filter) (iter.Seq2[*replayEvent, error], error) {
+	switch {
+	case c.String("journal") != "":
+		return journalSource(c.String("journal"), flt, journal.Direction(c.String("direction")))
+	case c.String("stream") != "":
+		return streamSource(ctx, c.String("stream"), c.Duration("idle"))
+	case c.String("file") != "":
+		return fileSource(c.String("file")), nil
+	}
+	return nil, cli.Exit("one of journal, stream or file sources is required", 1)
+}
+
+// isParseError of the single message which can be skipped
+func isParseError(err error) bool {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
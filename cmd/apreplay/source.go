@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"os"
+	"time"
+
+	nc "github.com/geniusrabbit/notificationcenter/v2"
+
+	"github.com/demdxx/asyncp/v2"
+	"github.com/demdxx/asyncp/v2/journal"
+	"github.com/demdxx/asyncp/v2/streams"
+)
+
+// journalSource reads received events from the journal directory
+func journalSource(dir string, flt *filter, direction journal.Direction) (iter.Seq2[*replayEvent, error], error) {
+	jrn, err := journal.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	records := jrn.Range(flt.from, flt.to)
+	if flt.rootID != "" {
+		records = jrn.Root(flt.rootID)
+	}
+	return func(yield func(*replayEvent, error) bool) {
+		defer jrn.Close()
+		for rec, err := range records {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(rec.Event) == 0 || (direction != "" && rec.Direction != direction) {
+				continue
+			}
+			ev, err := parseEvent(rec.Event, rec.Time)
+			if err == nil && ev.Err == "" {
+				ev.Err = rec.Err
+			}
+			if !yield(ev, err) {
+				return
+			}
+		}
+	}, nil
+}
+
+// fileSource reads JSONL dump of the events or journal records
+func fileSource(filename string) iter.Seq2[*replayEvent, error] {
+	return func(yield func(*replayEvent, error) bool) {
+		file, err := os.Open(filename)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer file.Close()
+		rd := bufio.NewReader(file)
+		for {
+			line, err := rd.ReadBytes('\n')
+			if len(line) > 0 {
+				if !yield(parseLine(line)) {
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
+
+func parseLine(line []byte) (*replayEvent, error) {
+	var rec journal.Record
+	if err := json.Unmarshal(line, &rec); err == nil && rec.Direction != "" && len(rec.Event) > 0 {
+		ev, err := parseEvent(rec.Event, rec.Time)
+		if err == nil && ev.Err == "" {
+			ev.Err = rec.Err
+		}
+		return ev, err
+	}
+	return parseEvent(line, time.Time{})
+}
+
+// streamSource reads events from the stream until it's idle.
+// Messages are acked by the replay only after the publishing.
+func streamSource(ctx context.Context, url string, idle time.Duration) (iter.Seq2[*replayEvent, error], error) {
+	sub, err := streams.SubscriberFromURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(*replayEvent, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		defer sub.Close()
+
+		messages := make(chan nc.Message)
+		err := sub.Subscribe(ctx, nc.FuncReceiver(func(msg nc.Message) error {
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+			}
+			return nil
+		}))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		go func() { _ = sub.Listen(ctx) }()
+
+		timer := time.NewTimer(idle)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				return
+			case msg := <-messages:
+				ev, err := parseMessage(msg)
+				if err == nil {
+					ev.ack = msg.Ack
+				}
+				if !yield(ev, err) {
+					return
+				}
+				timer.Reset(idle)
+			}
+		}
+	}, nil
+}
+
+// parseMessage converts CloudEvents binary message to the envelope
+func parseMessage(msg nc.Message) (*replayEvent, error) {
+	if hmsg, ok := msg.(asyncp.HeaderMessage); ok {
+		event, err := asyncp.DecodeCloudEventBinary(hmsg.Headers(), msg.Body(), nil)
+		if err == nil {
+			data, err := event.Encode()
+			if err != nil {
+				return nil, err
+			}
+			return parseEvent(data, time.Time{})
+		}
+	}
+	return parseEvent(msg.Body(), time.Time{})
+}
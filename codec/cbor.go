@@ -0,0 +1,18 @@
+//go:build !apnecbor
+
+package codec
+
+import "github.com/fxamacker/cbor/v2"
+
+// CBOR codec implementation
+var CBOR Codec = cborCodec{}
+
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string                { return "application/cbor" }
+func (cborCodec) Marshal(v any) ([]byte, error)      { return cbor.Marshal(v) }
+func (cborCodec) Unmarshal(data []byte, v any) error { return cbor.Unmarshal(data, v) }
+
+func init() {
+	Register(CBOR)
+}
@@ -0,0 +1,9 @@
+//go:build !apnecbor
+
+package codec
+
+import "testing"
+
+func TestCBORCodec(t *testing.T) {
+	testCodec(t, CBOR)
+}
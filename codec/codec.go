@@ -0,0 +1,63 @@
+// Package codec provides payload serialization formats and the registry
+// of formats by the content type.
+package codec
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedContentType in case of unknown payload content type
+var ErrUnsupportedContentType = errors.New(`unsupported content type`)
+
+// Codec describes payload serialization format
+type Codec interface {
+	// ContentType returns MIME type of the encoded data
+	ContentType() string
+
+	// Marshal value into the bytes
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal bytes into the target value
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	mx     sync.RWMutex
+	codecs = map[string]Codec{}
+)
+
+// Register codec by the content type
+func Register(c Codec) {
+	mx.Lock()
+	defer mx.Unlock()
+	codecs[c.ContentType()] = c
+}
+
+// ByContentType returns registered codec or nil.
+// Empty content type is considered as JSON for compatibility with old messages.
+func ByContentType(contentType string) Codec {
+	if contentType == `` {
+		return JSON
+	}
+	mx.RLock()
+	defer mx.RUnlock()
+	return codecs[contentType]
+}
+
+// Lookup codec by content type or return the error
+func Lookup(contentType string) (Codec, error) {
+	if c := ByContentType(contentType); c != nil {
+		return c, nil
+	}
+	return nil, errors.Wrap(ErrUnsupportedContentType, contentType)
+}
+
+// Or returns the codec or JSON if it's nil
+func Or(c Codec) Codec {
+	if c == nil {
+		return JSON
+	}
+	return c
+}
@@ -0,0 +1,36 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testItem struct {
+	Text  string `json:"text" msgpack:"text" cbor:"text"`
+	Count int    `json:"count" msgpack:"count" cbor:"count"`
+}
+
+func TestCodecs(t *testing.T) {
+	for _, c := range []Codec{JSON, Gob} {
+		t.Run(c.ContentType(), func(t *testing.T) { testCodec(t, c) })
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c, err := Lookup("")
+	assert.NoError(t, err)
+	assert.Equal(t, JSON, c)
+	_, err = Lookup("application/unknown")
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+	assert.Equal(t, JSON, Or(nil))
+}
+
+func testCodec(t *testing.T, c Codec) {
+	data, err := c.Marshal(&testItem{Text: "test", Count: 10})
+	assert.NoError(t, err)
+	var res testItem
+	assert.NoError(t, c.Unmarshal(data, &res))
+	assert.Equal(t, testItem{Text: "test", Count: 10}, res)
+	assert.Equal(t, c, ByContentType(c.ContentType()))
+}
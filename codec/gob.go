@@ -0,0 +1,29 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// Gob codec implementation
+var Gob Codec = gobCodec{}
+
+type gobCodec struct{}
+
+func (gobCodec) ContentType() string { return "application/x-gob" }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buff bytes.Buffer
+	if err := gob.NewEncoder(&buff).Encode(v); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func init() {
+	Register(Gob)
+}
@@ -0,0 +1,18 @@
+//go:build !apnemsgpack
+
+package codec
+
+import "github.com/vmihailenco/msgpack/v5"
+
+// MsgPack codec implementation
+var MsgPack Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string                { return "application/msgpack" }
+func (msgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+func init() {
+	Register(MsgPack)
+}
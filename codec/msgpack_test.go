@@ -0,0 +1,9 @@
+//go:build !apnemsgpack
+
+package codec
+
+import "testing"
+
+func TestMsgPackCodec(t *testing.T) {
+	testCodec(t, MsgPack)
+}
@@ -0,0 +1,40 @@
+//go:build !apneprotobuf
+
+package codec
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotProtoMessage in case of value is not a protobuf message
+var ErrNotProtoMessage = errors.New(`value is not a protobuf message`)
+
+// Protobuf codec implementation, supports only values of proto.Message type
+var Protobuf Codec = protobufCodec{}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return "application/x-protobuf" }
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.Wrap(ErrNotProtoMessage, fmt.Sprintf("%T", v))
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errors.Wrap(ErrNotProtoMessage, fmt.Sprintf("%T", v))
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func init() {
+	Register(Protobuf)
+}
@@ -0,0 +1,21 @@
+//go:build !apneprotobuf
+
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtobufCodec(t *testing.T) {
+	data, err := Protobuf.Marshal(wrapperspb.String("test"))
+	assert.NoError(t, err)
+	var res wrapperspb.StringValue
+	assert.NoError(t, Protobuf.Unmarshal(data, &res))
+	assert.Equal(t, "test", res.GetValue())
+
+	_, err = Protobuf.Marshal("test")
+	assert.ErrorIs(t, err, ErrNotProtoMessage)
+}
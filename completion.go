@@ -0,0 +1,176 @@
+package asyncp
+
+import (
+	"log"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+type (
+	// CompletionStore keeps processing state of the root events
+	CompletionStore = monitor.CompletionStore
+
+	// EventStatus of the root event processing by the task chain
+	EventStatus = monitor.EventStatus
+
+	// CompleteHandlerFnk of the finished chain with the result of the last task
+	CompleteHandlerFnk func(root Event, result Payload)
+
+	// FailedHandlerFnk of the finished chain with errors of the failed tasks
+	FailedHandlerFnk func(root Event, err error)
+)
+
+// completionTracker counts pending events of the chain by the root event ID.
+// Every response event increments the counter before it's sent
+// and every finished task decrements it, so the zero means the end
+// of the chain including the tasks of other applications of the cluster.
+type completionTracker struct {
+	store      CompletionStore
+	onComplete CompleteHandlerFnk
+	onFailed   FailedHandlerFnk
+}
+
+func newCompletionTracker(opts *Options) *completionTracker {
+	if opts.CompletionStore == nil && opts.OnComplete == nil && opts.OnFailed == nil {
+		return nil
+	}
+	store := opts.CompletionStore
+	if store == nil {
+		store = monitor.NewMemoryCompletionStore(0)
+	}
+	return &completionTracker{
+		store:      store,
+		onComplete: opts.OnComplete,
+		onFailed:   opts.OnFailed,
+	}
+}
+
+// isRootEvent returns true for the first event of the chain
+func isRootEvent(event Event) bool {
+	sent, _ := event.Counters()
+	return sent == 0 && len(event.DoneTasks()) == 0
+}
+
+// trackBegin of the root event processing
+func (srv *TaskMux) trackBegin(event Event) {
+	if srv.completion == nil || !isRootEvent(event) {
+		return
+	}
+	data, _ := srv.encodeStoredEvent(event)
+	if err := srv.completion.store.Begin(event.ID().String(), event.Name(), data); err != nil {
+		log.Printf("completion begin %s: %s", event.Name(), err.Error())
+	}
+}
+
+// trackSpawn of the new event of the chain
+func (srv *TaskMux) trackSpawn(event Event) {
+	if srv.completion == nil {
+		return
+	}
+	if err := srv.completion.store.Spawn(event.ID().String(), 1); err != nil {
+		log.Printf("completion spawn %s: %s", event.Name(), err.Error())
+	}
+}
+
+// trackResult of the last task of the chain
+func (srv *TaskMux) trackResult(result Event) {
+	if srv.completion == nil {
+		return
+	}
+	data, err := srv.encodeStoredEvent(result)
+	if err == nil {
+		err = srv.completion.store.Result(result.ID().String(), data)
+	}
+	if err != nil {
+		log.Printf("completion result: %s", err.Error())
+	}
+}
+
+// trackDone of the task and fires handlers if the chain is finished
+func (srv *TaskMux) trackDone(event Event, err error) {
+	if srv.completion == nil {
+		return
+	}
+	if err != nil && errors.Is(err, ErrSkipEvent) {
+		err = nil
+	}
+	id := event.ID().String()
+	pending, err := srv.completion.store.Done(id, event.Name(), err)
+	if err != nil {
+		log.Printf("completion done %s: %s", event.Name(), err.Error())
+		return
+	}
+	if pending != 0 {
+		return
+	}
+	event.SetComplete(true)
+	// Blobs are released after the handlers which read the root and the result payloads
+	defer func() {
+		if err := srv.envelope().releaseBlobs(srv.newExecContext(), event.ID()); err != nil {
+			log.Printf("release payload blobs %s: %s", id, err.Error())
+		}
+	}()
+	if srv.completion.onComplete == nil && srv.completion.onFailed == nil {
+		return
+	}
+	status, err := srv.completion.store.EventStatus(id)
+	if err != nil || status == nil {
+		log.Printf("completion status %s: %v", event.Name(), err)
+		return
+	}
+	root := srv.decodeTrackedEvent(status.Root)
+	if root == nil {
+		root = event
+	}
+	if status.IsFailed() {
+		if srv.completion.onFailed != nil {
+			srv.completion.onFailed(root, statusError(status))
+		}
+		return
+	}
+	if srv.completion.onComplete != nil {
+		var result Payload
+		if resultEvent := srv.decodeTrackedEvent(status.Result); resultEvent != nil {
+			result = resultEvent.Payload()
+		}
+		srv.completion.onComplete(root, result)
+	}
+}
+
+// EventStatus returns the processing state of the root event by ID
+func (srv *TaskMux) EventStatus(id string) (*EventStatus, error) {
+	if srv.completion == nil {
+		return nil, nil
+	}
+	return srv.completion.store.EventStatus(id)
+}
+
+func (srv *TaskMux) decodeTrackedEvent(data []byte) Event {
+	if len(data) == 0 {
+		return nil
+	}
+	ev := &event{}
+	if err := ev.decode(data, srv); err != nil {
+		log.Printf("completion decode event: %s", err.Error())
+		return nil
+	}
+	ev.SetMux(srv)
+	return ev
+}
+
+func statusError(status *EventStatus) error {
+	tasks := make([]string, 0, len(status.Errors))
+	for task := range status.Errors {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+	var err error
+	for _, task := range tasks {
+		err = multierr.Append(err, stringError(task+": "+status.Errors[task]))
+	}
+	return err
+}
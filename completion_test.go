@@ -0,0 +1,114 @@
+package asyncp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/blobstore"
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+func TestMuxCompletion(t *testing.T) {
+	var (
+		done      = make(chan struct{})
+		completed []int
+		roots     []string
+		failed    error
+		store     = monitor.NewMemoryCompletionStore(0)
+		mux       = NewTaskMux(
+			WithCompletionStore(store),
+			WithOnComplete(func(root Event, result Payload) {
+				var sum int
+				assert.NoError(t, result.Decode(&sum))
+				completed = append(completed, sum)
+				roots = append(roots, root.Name())
+				close(done)
+			}),
+			WithOnFailed(func(root Event, err error) { failed = err }),
+		)
+	)
+	mux.Handle("split", func(v []int) []int { return v }).
+		Then(func(i int) (*int, error) {
+			if i < 0 {
+				return nil, errors.New("negative")
+			}
+			return &i, nil
+		}).
+		Then(FuncTask(func(ctx context.Context, event Event, rw ResponseWriter) error {
+			var i int
+			if err := event.Payload().Decode(&i); err != nil {
+				return err
+			}
+			return rw.WriteResonse(i * 10)
+		}).Async())
+
+	ev := WithPayload("split", []int{1, 2, 3})
+	assert.NoError(t, mux.ExecuteEvent(ev))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("chain is not completed")
+	}
+	assert.NoError(t, mux.Close())
+	assert.Len(t, completed, 1, "the chain is completed once after all branches")
+	assert.Contains(t, []int{10, 20, 30}, completed[0])
+	assert.Equal(t, []string{"split"}, roots)
+
+	status, err := mux.EventStatus(ev.ID().String())
+	if assert.NoError(t, err) && assert.NotNil(t, status) {
+		assert.True(t, status.IsComplete())
+		assert.False(t, status.IsFailed())
+		assert.Equal(t, []string{"split", "split.1", "split.2"}, status.Tasks)
+	}
+	total, doneTasks := mux.CompleteTasks(ev)
+	assert.Equal(t, []string{"split", "split.1", "split.2"}, total)
+	assert.Equal(t, total, doneTasks)
+
+	mux = NewTaskMux(
+		WithCompletionStore(store),
+		WithOnFailed(func(root Event, err error) { failed = err }),
+	)
+	mux.Handle("split", func(v []int) []int { return v }).
+		Then(func(i int) (*int, error) {
+			if i < 0 {
+				return nil, errors.New("negative")
+			}
+			return &i, nil
+		})
+	_ = mux.ExecuteEvent(WithPayload("split", []int{1, -1}))
+	assert.ErrorContains(t, failed, "split.1: negative")
+}
+
+func TestMuxCompletionClaimCheck(t *testing.T) {
+	var (
+		rootText   string
+		resultText string
+		text       = strings.Repeat("claim-check ", 10)
+		blobs      = blobstore.NewMemory()
+		mux        = NewTaskMux(
+			WithClaimCheck(blobs, 10, time.Minute),
+			WithOnComplete(func(root Event, result Payload) {
+				assert.NoError(t, root.Payload().Decode(&rootText))
+				if assert.NotNil(t, result) {
+					assert.NoError(t, result.Decode(&resultText))
+				}
+			}),
+		)
+	)
+	mux.Handle("test", FuncTask(func(_ context.Context, event Event, rw ResponseWriter) error {
+		return rw.WriteResonse(event.Payload())
+	}))
+	ev := WithPayload("test", text)
+	ev.SetMux(mux)
+	data, err := ev.Encode()
+	assert.NoError(t, err)
+	assert.NoError(t, mux.Receive(message(data)))
+	assert.Equal(t, text, rootText)
+	assert.Equal(t, text, resultText)
+	assert.Equal(t, 0, blobs.Len(), "blobs are released after the handlers")
+}
@@ -0,0 +1,51 @@
+// Package compression provides payload compression algorithms
+// and the registry of algorithms by the name.
+package compression
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedCompression in case of unknown compression algorithm
+var ErrUnsupportedCompression = errors.New(`unsupported compression`)
+
+// Compressor describes payload compression algorithm
+type Compressor interface {
+	// Name of the algorithm stored in the event envelope
+	Name() string
+
+	// Compress data
+	Compress(data []byte) ([]byte, error)
+
+	// Decompress data
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	mx          sync.RWMutex
+	compressors = map[string]Compressor{}
+)
+
+// Register compressor by the name
+func Register(c Compressor) {
+	mx.Lock()
+	defer mx.Unlock()
+	compressors[c.Name()] = c
+}
+
+// ByName returns registered compressor or nil
+func ByName(name string) Compressor {
+	mx.RLock()
+	defer mx.RUnlock()
+	return compressors[name]
+}
+
+// Lookup compressor by the name or return the error
+func Lookup(name string) (Compressor, error) {
+	if c := ByName(name); c != nil {
+		return c, nil
+	}
+	return nil, errors.Wrap(ErrUnsupportedCompression, name)
+}
@@ -0,0 +1,25 @@
+package compression
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressors(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"title":"test","description":"compression"}`), 100)
+	for _, c := range []Compressor{Gzip, Zstd, Snappy} {
+		t.Run(c.Name(), func(t *testing.T) {
+			packed, err := c.Compress(data)
+			assert.NoError(t, err)
+			assert.Less(t, len(packed), len(data))
+			unpacked, err := c.Decompress(packed)
+			assert.NoError(t, err)
+			assert.Equal(t, data, unpacked)
+			assert.Equal(t, c, ByName(c.Name()))
+		})
+	}
+	_, err := Lookup("unknown")
+	assert.ErrorIs(t, err, ErrUnsupportedCompression)
+}
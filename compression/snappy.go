@@ -0,0 +1,22 @@
+package compression
+
+import "github.com/klauspost/compress/snappy"
+
+// Snappy compression implementation
+var Snappy Compressor = snappyCompressor{}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Name() string { return "snappy" }
+
+func (snappyCompressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (snappyCompressor) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+func init() {
+	Register(Snappy)
+}
@@ -0,0 +1,32 @@
+package compression
+
+import "github.com/klauspost/compress/zstd"
+
+// Zstd compression implementation
+var Zstd Compressor = newZstdCompressor()
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() *zstdCompressor {
+	// Encoder and decoder without options can't return the error
+	encoder, _ := zstd.NewWriter(nil)
+	decoder, _ := zstd.NewReader(nil)
+	return &zstdCompressor{encoder: encoder, decoder: decoder}
+}
+
+func (*zstdCompressor) Name() string { return "zstd" }
+
+func (c *zstdCompressor) Compress(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
+
+func init() {
+	Register(Zstd)
+}
@@ -0,0 +1,273 @@
+package asyncp
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+// Errors of the task control
+var (
+	ErrDraining   = errors.New(`mux is draining`)
+	ErrTaskPaused = errors.New(`task is paused`)
+)
+
+// AllTasks name of the pause which is applied to every task of the mux
+const AllTasks = monitor.AllTasks
+
+// drainCheckInterval of the in-flight events
+const drainCheckInterval = 10 * time.Millisecond
+
+// DefaultPauseBufferSize of the events held by the paused task
+const DefaultPauseBufferSize = 1000
+
+// errEventHeld is returned for the received event which is acked after the resume
+var errEventHeld = errors.New(`event is held`)
+
+// heldEvent of the paused task with the message which is acked after the execution
+type heldEvent struct {
+	event Event
+	msg   Message
+}
+
+// pausedTask keeps the events held until the resume
+type pausedTask struct {
+	events []heldEvent
+
+	// requeue events received from the stream instead of holding
+	requeue bool
+}
+
+// taskControl keeps runtime state of the task execution
+type taskControl struct {
+	mx sync.Mutex
+
+	// paused tasks by name or AllTasks
+	paused map[string]*pausedTask
+
+	draining atomic.Bool
+
+	// drained is closed when the draining starts
+	drained chan struct{}
+
+	// bufferSize of the held events of every paused task
+	bufferSize int
+
+	// inflight events executed by the mux
+	inflight atomic.Int64
+}
+
+// PauseTask holds new events of the task in memory until it's resumed.
+// Events received from the stream are acked only after the execution,
+// so the broker redelivers them if the node stops during the pause.
+// If the buffer of the held events is full, new events are returned with ErrTaskPaused.
+// Events which are already in process are not affected.
+// The AllTasks name pauses every task of the mux.
+func (srv *TaskMux) PauseTask(name string) {
+	srv.pauseTask(name, false)
+}
+
+// PauseTaskWithRequeue returns new events of the task received from the stream
+// with ErrTaskPaused without the ack, so the broker redelivers them to other nodes.
+// Events produced in-process are held until the resume.
+func (srv *TaskMux) PauseTaskWithRequeue(name string) {
+	srv.pauseTask(name, true)
+}
+
+func (srv *TaskMux) pauseTask(name string, requeue bool) {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	if srv.control.paused == nil {
+		srv.control.paused = map[string]*pausedTask{}
+	}
+	if p := srv.control.paused[name]; p != nil {
+		p.requeue = requeue
+	} else {
+		srv.control.paused[name] = &pausedTask{requeue: requeue}
+	}
+}
+
+// ResumeTask executes held events of the task and process new ones
+func (srv *TaskMux) ResumeTask(name string) {
+	srv.control.mx.Lock()
+	p := srv.control.paused[name]
+	delete(srv.control.paused, name)
+	srv.control.mx.Unlock()
+	if p == nil || len(p.events) == 0 {
+		return
+	}
+	srv.control.inflight.Add(1)
+	go func() {
+		defer srv.control.inflight.Add(-1)
+		for _, held := range p.events {
+			err := srv.executeEvent(held.event, held.msg)
+			switch {
+			case errors.Is(err, errEventHeld):
+			case err != nil:
+				log.Printf("resume %s: %s", held.event.Name(), err.Error())
+			case held.msg != nil:
+				if err = held.msg.Ack(); err != nil {
+					log.Printf("resume %s: %s", held.event.Name(), err.Error())
+				}
+			}
+			ReleaseEvent(held.event)
+		}
+	}()
+}
+
+// IsTaskPaused returns true if events of the task are held or requeued
+func (srv *TaskMux) IsTaskPaused(name string) bool {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	return srv.pausedTask(name) != nil
+}
+
+// PausedTasks returns paused tasks with counts of the held events
+func (srv *TaskMux) PausedTasks() map[string]int {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	tasks := make(map[string]int, len(srv.control.paused))
+	for name, p := range srv.control.paused {
+		tasks[name] = len(p.events)
+	}
+	return tasks
+}
+
+// pausedTask returns the pause of the task or the whole mux, the lock must be held
+func (srv *TaskMux) pausedTask(name string) *pausedTask {
+	if p := srv.control.paused[name]; p != nil {
+		return p
+	}
+	return srv.control.paused[AllTasks]
+}
+
+// holdEvent of the paused task with the message of the stream if it's received.
+// It returns false if the task is not paused, errEventHeld if the message must not be acked
+// and ErrTaskPaused if the buffer of the task is full.
+func (srv *TaskMux) holdEvent(event Event, msg Message) (bool, error) {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	p := srv.pausedTask(event.Name())
+	switch {
+	case p == nil:
+		return false, nil
+	case len(p.events) >= srv.pauseBufferSize():
+		return true, errors.Wrap(ErrTaskPaused, event.Name())
+	}
+	p.events = append(p.events, heldEvent{event: RetainEvent(event), msg: msg})
+	if msg != nil {
+		return true, errEventHeld
+	}
+	return true, nil
+}
+
+func (srv *TaskMux) pauseBufferSize() int {
+	if srv.control.bufferSize > 0 {
+		return srv.control.bufferSize
+	}
+	return DefaultPauseBufferSize
+}
+
+// isRequeued returns true if received events of the task must be returned to the broker
+func (srv *TaskMux) isRequeued(name string) bool {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	p := srv.pausedTask(name)
+	return p != nil && p.requeue
+}
+
+// Drain stops receiving of new events from the stream and waits until
+// all in-flight events including async tasks are processed or the context is done.
+// Responses of the in-flight events are still processed in place.
+// Subscribers of the mux should be stopped on the Drained signal.
+func (srv *TaskMux) Drain(ctx context.Context) error {
+	srv.startDrain()
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for !srv.isIdle() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// CancelDrain resumes receiving of new events from the stream
+func (srv *TaskMux) CancelDrain() {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	if srv.control.draining.Swap(false) {
+		srv.control.drained = nil
+	}
+}
+
+// Drained returns the channel which is closed when the draining starts,
+// the subscribers of the mux are stopped by it to prevent the redelivery of the rejected events.
+// The new channel is returned after CancelDrain.
+func (srv *TaskMux) Drained() <-chan struct{} {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	if srv.control.drained == nil {
+		srv.control.drained = make(chan struct{})
+		if srv.control.draining.Load() {
+			close(srv.control.drained)
+		}
+	}
+	return srv.control.drained
+}
+
+func (srv *TaskMux) startDrain() {
+	srv.control.mx.Lock()
+	defer srv.control.mx.Unlock()
+	if !srv.control.draining.Swap(true) && srv.control.drained != nil {
+		close(srv.control.drained)
+	}
+}
+
+// IsDraining returns true if the mux doesn't receive new events
+func (srv *TaskMux) IsDraining() bool {
+	return srv.control.draining.Load()
+}
+
+// InFlight returns count of the events executed by the mux at the moment
+// without events of the async task pools
+func (srv *TaskMux) InFlight() int64 {
+	return srv.control.inflight.Load()
+}
+
+// AsyncStats returns execution pool stats of the async tasks
+func (srv *TaskMux) AsyncStats() map[string]AsyncStats {
+	stats := map[string]AsyncStats{}
+	for name, task := range srv.tasksRegistry().tasks {
+		if asyncTask, ok := task.Task().(*AsyncTask); ok {
+			stats[name] = asyncTask.Stats()
+		}
+	}
+	return stats
+}
+
+func (srv *TaskMux) isIdle() bool {
+	if srv.control.inflight.Load() > 0 {
+		return false
+	}
+	reg := srv.tasksRegistry()
+	for _, task := range reg.tasks {
+		if asyncTask, ok := task.Task().(*AsyncTask); ok && !asyncTask.isIdle() {
+			return false
+		}
+	}
+	if reg.failoverTask != nil {
+		if asyncTask, ok := reg.failoverTask.Task().(*AsyncTask); ok && !asyncTask.isIdle() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package asyncp
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+type testAckMessage struct {
+	message
+	acks *atomic.Int32
+}
+
+func (m testAckMessage) Ack() error {
+	m.acks.Add(1)
+	return nil
+}
+
+func TestMuxPauseResume(t *testing.T) {
+	var (
+		count atomic.Int32
+		acks  atomic.Int32
+		mux   = NewTaskMux(WithPauseBufferSize(2))
+	)
+	mux.Handle("count", func(n int) error { count.Add(int32(n)); return nil })
+	newMessage := func(n int) Message {
+		return testAckMessage{message: mustMessageFrom(WithPayload("count", n)), acks: &acks}
+	}
+
+	mux.PauseTask("count")
+	assert.True(t, mux.IsTaskPaused("count"))
+	assert.NoError(t, mux.Receive(newMessage(1)))
+	assert.NoError(t, mux.Receive(newMessage(2)))
+	assert.ErrorIs(t, mux.Receive(newMessage(4)), ErrTaskPaused, "buffer is full")
+	assert.Equal(t, int32(0), count.Load())
+	assert.Equal(t, int32(0), acks.Load(), "held messages are acked after the resume")
+	assert.Equal(t, map[string]int{"count": 2}, mux.PausedTasks())
+
+	mux.ResumeTask("count")
+	assert.False(t, mux.IsTaskPaused("count"))
+	assert.Eventually(t, func() bool { return acks.Load() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), count.Load())
+	assert.Empty(t, mux.PausedTasks())
+}
+
+func TestMuxDrain(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		mux     = NewTaskMux()
+	)
+	mux.Handle("wait", FuncTask(func(context.Context, Event, ResponseWriter) error {
+		<-release
+		return nil
+	}).Async())
+
+	assert.NoError(t, mux.Receive(mustMessageFrom(WithPayload("wait", 1))))
+	assert.Eventually(t, func() bool { return mux.AsyncStats()["wait"].InProcess == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	drained := mux.Drained()
+	assert.ErrorIs(t, mux.Drain(ctx), context.DeadlineExceeded)
+	assert.True(t, mux.IsDraining())
+	select {
+	case <-drained:
+	default:
+		t.Error("drained signal is not sent")
+	}
+	assert.ErrorIs(t, mux.Receive(mustMessageFrom(WithPayload("wait", 1))), ErrDraining)
+
+	close(release)
+	assert.NoError(t, mux.Drain(context.Background()))
+	assert.Equal(t, AsyncStats{}, mux.AsyncStats()["wait"])
+	assert.NoError(t, mux.Close())
+}
+
+type testControlStore struct {
+	controls []*Control
+}
+
+func (s *testControlStore) SetControl(ctrl *Control) error {
+	s.controls = append(s.controls, ctrl)
+	return nil
+}
+
+func (s *testControlStore) DeleteControl(ctrl *Control) error {
+	s.controls = slices.DeleteFunc(s.controls, func(c *Control) bool { return *c == *ctrl })
+	return nil
+}
+
+func (s *testControlStore) Controls() ([]*Control, error) { return s.controls, nil }
+
+func TestMuxControl(t *testing.T) {
+	var (
+		count atomic.Int32
+		store = &testControlStore{}
+		mux   = NewTaskMux(WithControl(store, time.Hour))
+	)
+	mux.Handle("count", func(n int) error { count.Add(int32(n)); return nil })
+	assert.NoError(t, mux.FinishInit())
+	defer func() { assert.NoError(t, mux.Close()) }()
+
+	pause := &Control{Task: "count", Action: monitor.ControlPause}
+	drain := &Control{Task: "count", Action: monitor.ControlDrain, Host: "other"}
+	_ = store.SetControl(pause)
+	_ = store.SetControl(drain)
+	assert.NoError(t, mux.SyncControls())
+	assert.True(t, mux.IsTaskPaused("count"))
+	assert.NoError(t, mux.Receive(mustMessageFrom(WithPayload("count", 1))))
+	assert.Equal(t, int32(0), count.Load())
+
+	// Drained task returns events to the broker
+	drain.Host = ""
+	assert.NoError(t, mux.SyncControls())
+	assert.ErrorIs(t, mux.Receive(mustMessageFrom(WithPayload("count", 1))), ErrTaskPaused)
+
+	_ = store.DeleteControl(pause)
+	_ = store.DeleteControl(drain)
+	_ = store.SetControl(&Control{Task: AllTasks, Action: monitor.ControlDrain})
+	assert.NoError(t, mux.SyncControls())
+	assert.False(t, mux.IsTaskPaused("count"))
+	assert.True(t, mux.IsDraining())
+	assert.Eventually(t, func() bool { return count.Load() == 1 }, time.Second, time.Millisecond)
+
+	store.controls = nil
+	assert.NoError(t, mux.SyncControls())
+	assert.False(t, mux.IsDraining())
+}
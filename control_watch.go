@@ -0,0 +1,162 @@
+package asyncp
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+type (
+	// ControlStore keeps control states of the cluster nodes
+	ControlStore = monitor.ControlStore
+
+	// Control state of the task processing by the cluster nodes
+	Control = monitor.Control
+)
+
+// DefaultControlInterval of the control store watching
+const DefaultControlInterval = 5 * time.Second
+
+// controlWatcher applies control states of the store to the mux.
+// Only tasks paused by the watcher are resumed when the state is deleted,
+// so pauses of the admin API are kept.
+type controlWatcher struct {
+	mx       sync.Mutex
+	store    ControlStore
+	interval time.Duration
+	cancel   context.CancelFunc
+
+	// applied actions by the task name
+	applied  map[string]monitor.ControlAction
+	draining bool
+}
+
+func newControlWatcher(opts *Options) *controlWatcher {
+	if opts.ControlStore == nil {
+		return nil
+	}
+	interval := opts.ControlInterval
+	if interval <= 0 {
+		interval = DefaultControlInterval
+	}
+	return &controlWatcher{store: opts.ControlStore, interval: interval}
+}
+
+// watchControls applies the current state of the store and watches it in background
+func (srv *TaskMux) watchControls(ctx context.Context) {
+	w := srv.controlWatcher
+	if w == nil {
+		return
+	}
+	if err := srv.SyncControls(); err != nil {
+		log.Printf("sync controls: %s", err.Error())
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if w.cancel != nil {
+		return
+	}
+	ctx, w.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := srv.SyncControls(); err != nil {
+					log.Printf("sync controls: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+func (srv *TaskMux) stopControls() {
+	w := srv.controlWatcher
+	if w == nil {
+		return
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
+
+// SyncControls applies control states of the store to the mux.
+// Paused tasks hold new events, drained tasks return received events to the broker
+// and the drained node stops receiving of new events.
+func (srv *TaskMux) SyncControls() error {
+	w := srv.controlWatcher
+	if w == nil {
+		return nil
+	}
+	controls, err := w.store.Controls()
+	if err != nil {
+		return err
+	}
+	var (
+		app, host, hostname = srv.nodeInfo()
+		desired             = map[string]monitor.ControlAction{}
+		draining            = false
+	)
+	for _, ctrl := range controls {
+		if !ctrl.Match(app, host, hostname) {
+			continue
+		}
+		if !ctrl.IsNode() {
+			if desired[ctrl.Task] != monitor.ControlDrain {
+				desired[ctrl.Task] = ctrl.Action
+			}
+			continue
+		}
+		if ctrl.Action == monitor.ControlDrain {
+			draining = true
+		} else {
+			desired[AllTasks] = ctrl.Action
+		}
+	}
+
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	for name := range w.applied {
+		if _, ok := desired[name]; !ok {
+			srv.ResumeTask(name)
+		}
+	}
+	for name, action := range desired {
+		if w.applied[name] == action {
+			continue
+		}
+		if action == monitor.ControlDrain {
+			srv.PauseTaskWithRequeue(name)
+		} else {
+			srv.PauseTask(name)
+		}
+	}
+	w.applied = desired
+	switch {
+	case draining && !w.draining:
+		srv.startDrain()
+	case !draining && w.draining:
+		srv.CancelDrain()
+	}
+	w.draining = draining
+	return nil
+}
+
+// nodeInfo returns application name and host of the node
+func (srv *TaskMux) nodeInfo() (app, host, hostname string) {
+	if cluster, _ := srv.cluster.(*Cluster); cluster != nil {
+		return cluster.appName, cluster.hostIP, cluster.hostname
+	}
+	hostname, _ = os.Hostname()
+	return "", localIP(), hostname
+}
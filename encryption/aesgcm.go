@@ -0,0 +1,90 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// AESGCM encryptor implementation
+type AESGCM struct {
+	mx       sync.RWMutex
+	provider KeyProvider
+	ciphers  map[string]cipher.AEAD
+}
+
+// NewAESGCM returns AES-GCM encryptor with keys from provider.
+// Keys must be 16, 24 or 32 bytes length to select AES-128, AES-192 or AES-256.
+func NewAESGCM(provider KeyProvider) *AESGCM {
+	return &AESGCM{provider: provider, ciphers: map[string]cipher.AEAD{}}
+}
+
+// Encrypt data with the current key, the nonce is prepended to the result
+func (enc *AESGCM) Encrypt(data, aad []byte) (string, []byte, error) {
+	keyID, key, err := enc.provider.CurrentKey()
+	if err != nil {
+		return ``, nil, err
+	}
+	aead, err := enc.cipher(keyID, key)
+	if err != nil {
+		return ``, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return ``, nil, err
+	}
+	return keyID, aead.Seal(nonce, nonce, data, additionalData(keyID, aad)), nil
+}
+
+// Decrypt data with the key by ID
+func (enc *AESGCM) Decrypt(keyID string, data, aad []byte) ([]byte, error) {
+	key, err := enc.provider.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := enc.cipher(keyID, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrInvalidMessage
+	}
+	nonce, encrypted := data[:aead.NonceSize()], data[aead.NonceSize():]
+	res, err := aead.Open(nil, nonce, encrypted, additionalData(keyID, aad))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+	return res, nil
+}
+
+// additionalData binds the key ID to the authenticated data
+func additionalData(keyID string, aad []byte) []byte {
+	res := make([]byte, 0, len(keyID)+1+len(aad))
+	res = append(res, keyID...)
+	res = append(res, 0)
+	return append(res, aad...)
+}
+
+// cipher returns cached cipher of the key
+func (enc *AESGCM) cipher(keyID string, key []byte) (cipher.AEAD, error) {
+	enc.mx.RLock()
+	aead := enc.ciphers[keyID]
+	enc.mx.RUnlock()
+	if aead != nil {
+		return aead, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidKey, keyID)
+	}
+	if aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	enc.mx.Lock()
+	defer enc.mx.Unlock()
+	enc.ciphers[keyID] = aead
+	return aead, nil
+}
@@ -0,0 +1,33 @@
+// Package encryption provides payload encryption with the rotated keys.
+package encryption
+
+import "github.com/pkg/errors"
+
+// Error list...
+var (
+	ErrUndefinedKey   = errors.New(`undefined encryption key`)
+	ErrInvalidKey     = errors.New(`invalid encryption key`)
+	ErrInvalidMessage = errors.New(`invalid encrypted message`)
+)
+
+// Encryptor describes payload encryption algorithm.
+// Additional data (aad) is authenticated but not encrypted, together with the key ID
+// it binds the encrypted data to the message.
+type Encryptor interface {
+	// Encrypt data with the current key and returns the key ID
+	Encrypt(data, aad []byte) (keyID string, encrypted []byte, err error)
+
+	// Decrypt data with the key by ID
+	Decrypt(keyID string, data, aad []byte) ([]byte, error)
+}
+
+// KeyProvider returns encryption keys by ID.
+// Old keys have to be available until all messages encrypted with them are processed,
+// the key data must never be changed for the same ID.
+type KeyProvider interface {
+	// CurrentKey returns the key for the new messages encryption
+	CurrentKey() (id string, key []byte, err error)
+
+	// Key returns the key by ID
+	Key(id string) ([]byte, error)
+}
@@ -0,0 +1,78 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAESGCMKeyRotation(t *testing.T) {
+	var (
+		key1 = []byte("0123456789abcdef")
+		key2 = []byte("0123456789abcdef0123456789abcdef")
+		enc1 = NewAESGCM(NewStaticKeyProvider("k1", map[string][]byte{"k1": key1}))
+		enc2 = NewAESGCM(NewStaticKeyProvider("k2", map[string][]byte{"k1": key1, "k2": key2}))
+	)
+	aad := []byte("event")
+	keyID, data, err := enc1.Encrypt([]byte("secret"), aad)
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", keyID)
+	assert.NotContains(t, string(data), "secret")
+
+	// Old messages are still readable after the rotation
+	res, err := enc2.Decrypt(keyID, data, aad)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(res))
+
+	// Data can't be moved into another message
+	_, err = enc2.Decrypt(keyID, data, []byte("other event"))
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+
+	keyID, data, err = enc2.Encrypt([]byte("secret"), aad)
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", keyID)
+	_, err = enc1.Decrypt(keyID, data, aad)
+	assert.ErrorIs(t, err, ErrUndefinedKey)
+
+	data[len(data)-1]++
+	_, err = enc2.Decrypt(keyID, data, aad)
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	var (
+		key      = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+		filename = filepath.Join(t.TempDir(), "keys.json")
+	)
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"current":"k1","keys":{"k1":"`+key+`"}}`), 0o600))
+	prov, err := NewFileKeyProvider(filename)
+	assert.NoError(t, err)
+	id, _, err := prov.CurrentKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", id)
+
+	// New key is loaded on the first request after the miss interval
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"current":"k2","keys":{"k1":"`+key+`","k2":"`+key+`"}}`), 0o600))
+	_, err = prov.Key("k2")
+	assert.ErrorIs(t, err, ErrUndefinedKey, "the file was checked recently")
+	prov.missInterval = time.Millisecond
+	time.Sleep(time.Millisecond * 2)
+	_, err = prov.Key("k2")
+	assert.NoError(t, err)
+}
+
+func TestEnvKeyProvider(t *testing.T) {
+	t.Setenv("TEST_ENC_CURRENT", "k1")
+	t.Setenv("TEST_ENC_KEY_k1", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")))
+	prov := NewEnvKeyProvider("TEST_ENC_")
+	id, key, err := prov.CurrentKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", id)
+	assert.Len(t, key, 16)
+	_, err = prov.Key("k2")
+	assert.ErrorIs(t, err, ErrUndefinedKey)
+}
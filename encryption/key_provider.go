@@ -0,0 +1,211 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultKeyFileCheckInterval = time.Second * 10
+
+	// defaultKeyFileMissInterval limits reloads of the file for unknown key IDs
+	defaultKeyFileMissInterval = time.Second
+)
+
+// StaticKeyProvider with the fixed set of keys
+type StaticKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyProvider returns provider with the current key ID and the set of keys
+func NewStaticKeyProvider(current string, keys map[string][]byte) *StaticKeyProvider {
+	return &StaticKeyProvider{current: current, keys: keys}
+}
+
+// CurrentKey returns the key for the new messages encryption
+func (p *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := p.Key(p.current)
+	return p.current, key, err
+}
+
+// Key returns the key by ID
+func (p *StaticKeyProvider) Key(id string) ([]byte, error) {
+	if key := p.keys[id]; len(key) > 0 {
+		return key, nil
+	}
+	return nil, errors.Wrap(ErrUndefinedKey, id)
+}
+
+// keyFile format of the file with keys
+//
+//	{"current": "key2", "keys": {"key1": "base64 encoded key", "key2": "base64 encoded key"}}
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// FileKeyProvider reads keys from JSON file and reloads them after the file was changed
+type FileKeyProvider struct {
+	mx sync.RWMutex
+
+	filepath      string
+	checkInterval time.Duration
+	missInterval  time.Duration
+	lastCheck     time.Time
+	modTime       time.Time
+	size          int64
+
+	keys *StaticKeyProvider
+}
+
+// NewFileKeyProvider returns provider of keys from JSON file
+//
+//	{"current": "key2", "keys": {"key1": "base64 encoded key", "key2": "base64 encoded key"}}
+//
+// The file modification is checked not more often than checkInterval (10 seconds by default)
+func NewFileKeyProvider(filepath string, checkInterval ...time.Duration) (*FileKeyProvider, error) {
+	prov := &FileKeyProvider{
+		filepath:      filepath,
+		checkInterval: defaultKeyFileCheckInterval,
+		missInterval:  defaultKeyFileMissInterval,
+	}
+	if len(checkInterval) > 0 && checkInterval[0] > 0 {
+		prov.checkInterval = checkInterval[0]
+	}
+	if err := prov.Reload(); err != nil {
+		return nil, err
+	}
+	return prov, nil
+}
+
+// CurrentKey returns the key for the new messages encryption
+func (p *FileKeyProvider) CurrentKey() (string, []byte, error) {
+	return p.provider().CurrentKey()
+}
+
+// Key returns the key by ID
+func (p *FileKeyProvider) Key(id string) ([]byte, error) {
+	key, err := p.provider().Key(id)
+	if errors.Is(err, ErrUndefinedKey) && p.canReloadOnMiss() {
+		// The key could be added recently so check the file again
+		if err = p.Reload(); err != nil {
+			return nil, err
+		}
+		return p.provider().Key(id)
+	}
+	return key, err
+}
+
+// Reload keys from the file if it was modified
+func (p *FileKeyProvider) Reload() error {
+	stat, err := os.Stat(p.filepath)
+	if err != nil {
+		return err
+	}
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.lastCheck = time.Now()
+	if p.keys != nil && stat.ModTime().Equal(p.modTime) && stat.Size() == p.size {
+		return nil
+	}
+	data, err := os.ReadFile(p.filepath)
+	if err != nil {
+		return err
+	}
+	var file keyFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	keys, err := decodeKeys(file.Keys)
+	if err != nil {
+		return err
+	}
+	p.keys = NewStaticKeyProvider(file.Current, keys)
+	p.modTime = stat.ModTime()
+	p.size = stat.Size()
+	return nil
+}
+
+// canReloadOnMiss returns true if the file wasn't checked recently,
+// so messages with unknown key IDs don't read the file every time
+func (p *FileKeyProvider) canReloadOnMiss() bool {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	return time.Since(p.lastCheck) >= p.missInterval
+}
+
+func (p *FileKeyProvider) provider() *StaticKeyProvider {
+	p.mx.RLock()
+	keys, needCheck := p.keys, time.Since(p.lastCheck) > p.checkInterval
+	p.mx.RUnlock()
+	if needCheck {
+		// Keep the previous keys if the file is temporarily unavailable
+		if err := p.Reload(); err == nil {
+			p.mx.RLock()
+			keys = p.keys
+			p.mx.RUnlock()
+		}
+	}
+	return keys
+}
+
+// EnvKeyProvider reads keys from environment variables.
+// {prefix}CURRENT contains ID of the current key and {prefix}KEY_{ID} contains base64 encoded key.
+type EnvKeyProvider struct {
+	prefix string
+}
+
+// NewEnvKeyProvider returns provider of keys from environment variables
+//
+//	APP_ENCRYPTION_CURRENT=key2
+//	APP_ENCRYPTION_KEY_key1=base64 encoded key
+//	APP_ENCRYPTION_KEY_key2=base64 encoded key
+func NewEnvKeyProvider(prefix string) *EnvKeyProvider {
+	return &EnvKeyProvider{prefix: prefix}
+}
+
+// CurrentKey returns the key for the new messages encryption
+func (p *EnvKeyProvider) CurrentKey() (string, []byte, error) {
+	id := os.Getenv(p.prefix + "CURRENT")
+	if id == `` {
+		return ``, nil, errors.Wrap(ErrUndefinedKey, p.prefix+"CURRENT")
+	}
+	key, err := p.Key(id)
+	return id, key, err
+}
+
+// Key returns the key by ID
+func (p *EnvKeyProvider) Key(id string) ([]byte, error) {
+	val := os.Getenv(p.prefix + "KEY_" + id)
+	if val == `` {
+		return nil, errors.Wrap(ErrUndefinedKey, id)
+	}
+	return decodeKey(id, val)
+}
+
+func decodeKeys(keys map[string]string) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(keys))
+	for id, val := range keys {
+		key, err := decodeKey(id, val)
+		if err != nil {
+			return nil, err
+		}
+		res[id] = key
+	}
+	return res, nil
+}
+
+func decodeKey(id, val string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(val))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidKey, id)
+	}
+	return key, nil
+}
@@ -0,0 +1,149 @@
+package asyncp
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2/blobstore"
+	"github.com/demdxx/asyncp/v2/compression"
+	"github.com/demdxx/asyncp/v2/encryption"
+)
+
+// ErrEncryptorUndefined in case of encrypted event without encryptor
+var ErrEncryptorUndefined = errors.New(`encryptor is undefined`)
+
+// BlobStore describes storage of the large payloads
+type BlobStore = blobstore.BlobStore
+
+// Compressor describes payload compression algorithm
+type Compressor = compression.Compressor
+
+// Encryptor describes payload encryption algorithm
+type Encryptor = encryption.Encryptor
+
+// envelopeOptions of the event envelope encoding
+type envelopeOptions struct {
+	// envelopeVersion of the produced events, the first version by default
+	envelopeVersion int
+
+	// compressor of the payloads larger then compressMinSize
+	compressor      Compressor
+	compressMinSize int
+
+	// encryptor of the payloads
+	encryptor Encryptor
+
+	// blobStore keeps payloads larger then blobThreshold out of the message broker
+	blobStore     BlobStore
+	blobThreshold int
+	blobTTL       time.Duration
+
+	// cloudEvents encoding of the events instead of the internal envelope
+	cloudEvents *CloudEventsOptions
+}
+
+// withCompression returns copy of options with the new compression
+func (opts *envelopeOptions) withCompression(c Compressor, minSize int) *envelopeOptions {
+	var newOpts envelopeOptions
+	if opts != nil {
+		newOpts = *opts
+	}
+	newOpts.compressor = c
+	newOpts.compressMinSize = minSize
+	return &newOpts
+}
+
+// version of the produced envelope
+func (opts *envelopeOptions) version() int {
+	if opts == nil || opts.envelopeVersion <= 0 {
+		return defaultEnvelopeVersion
+	}
+	return opts.envelopeVersion
+}
+
+// compress payload data and returns the name of the algorithm if compression was applied
+func (opts *envelopeOptions) compress(data []byte) ([]byte, string, error) {
+	if opts == nil || opts.compressor == nil || len(data) == 0 || len(data) < opts.compressMinSize {
+		return data, ``, nil
+	}
+	packed, err := opts.compressor.Compress(data)
+	if err != nil {
+		return nil, ``, err
+	}
+	// Compression makes no sense if it doesn't reduce the size
+	if len(packed) >= len(data) {
+		return data, ``, nil
+	}
+	return packed, opts.compressor.Name(), nil
+}
+
+// encrypt payload data of the event and returns the key ID if encryption was applied
+func (opts *envelopeOptions) encrypt(data []byte, id uuid.UUID, name string) ([]byte, string, error) {
+	if opts == nil || opts.encryptor == nil || len(data) == 0 {
+		return data, ``, nil
+	}
+	keyID, encrypted, err := opts.encryptor.Encrypt(data, encryptionAAD(id, name))
+	if err != nil {
+		return nil, ``, err
+	}
+	return encrypted, keyID, nil
+}
+
+// decrypt payload data of the event with the key
+func (opts *envelopeOptions) decrypt(data []byte, keyID string, id uuid.UUID, name string) ([]byte, error) {
+	if keyID == `` || len(data) == 0 {
+		return data, nil
+	}
+	if opts == nil || opts.encryptor == nil {
+		return nil, errors.Wrap(ErrEncryptorUndefined, keyID)
+	}
+	return opts.encryptor.Decrypt(keyID, data, encryptionAAD(id, name))
+}
+
+// encryptionAAD binds the encrypted payload to the event ID and name
+func encryptionAAD(id uuid.UUID, name string) []byte {
+	return append(id[:], name...)
+}
+
+// storeOptions of the events saved out of the stream like the journal.
+// Payloads are encrypted as in the stream, but never compressed or moved into the blob store.
+func (opts *envelopeOptions) storeOptions() *envelopeOptions {
+	if opts == nil || opts.encryptor == nil {
+		return nil
+	}
+	return &envelopeOptions{
+		envelopeVersion: opts.envelopeVersion,
+		encryptor:       opts.encryptor,
+		// Payloads in the blob store are kept as references
+		blobStore:     opts.blobStore,
+		blobThreshold: math.MaxInt,
+	}
+}
+
+// encodeStoredEvent to keep it out of the stream, payloads are encrypted
+// by the encryptor of the mux and payloads in the blob store are kept as references
+func (srv *TaskMux) encodeStoredEvent(ev Event) ([]byte, error) {
+	if e, ok := ev.(*event); ok {
+		return e.encode(srv.envelope().storeOptions())
+	}
+	return ev.Encode()
+}
+
+// isMeasured returns true if payload sizes have to be sent to monitor
+func (opts *envelopeOptions) isMeasured() bool {
+	return opts != nil && opts.compressor != nil
+}
+
+func decompressPayload(data []byte, name string) ([]byte, error) {
+	if name == `` || len(data) == 0 {
+		return data, nil
+	}
+	c, err := compression.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decompress(data)
+}
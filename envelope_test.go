@@ -0,0 +1,163 @@
+package asyncp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/blobstore"
+	"github.com/demdxx/asyncp/v2/compression"
+	"github.com/demdxx/asyncp/v2/encryption"
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+type payloadMetric struct {
+	dummyMetric
+	rawSize, encodedSize int
+}
+
+func (m *payloadMetric) EncodePayload(_ monitor.EventType, rawSize, encodedSize int) error {
+	m.rawSize += rawSize
+	m.encodedSize += encodedSize
+	return nil
+}
+
+func TestEventCompression(t *testing.T) {
+	var (
+		text   = strings.Repeat("compression ", 100)
+		metric = &payloadMetric{}
+		mux    = NewTaskMux(
+			WithCompression(compression.Gzip, 100),
+			WithCluster("test", ClusterWithStores(metric)),
+		)
+		ev = WithPayload("test", text)
+	)
+	ev.SetMux(mux)
+	_, err := ev.Encode()
+	assert.NoError(t, err)
+	assert.Zero(t, metric.rawSize, "only published payloads are measured")
+
+	pub := &testMessagePublisher{}
+	assert.NoError(t, publishEvent(context.Background(), pub, ev))
+	data, err := json.Marshal(pub.messages[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"compression":"gzip"`)
+	assert.Greater(t, metric.rawSize, metric.encodedSize)
+
+	// Repeated encoding of the same publishing is measured once
+	rawSize := metric.rawSize
+	_, err = ev.Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, rawSize, metric.rawSize)
+
+	var (
+		res   string
+		newEv event
+		small = WithPayload("test", "small")
+	)
+	assert.NoError(t, newEv.Decode(data))
+	assert.NoError(t, newEv.Payload().Decode(&res))
+	assert.Equal(t, text, res)
+
+	small.SetMux(mux)
+	dataSm, err := small.Encode()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(dataSm), `"compression"`)
+}
+
+func TestPublisherWithCompression(t *testing.T) {
+	var (
+		res  string
+		text = strings.Repeat("compression ", 100)
+		pub  = &testMessagePublisher{}
+	)
+	err := PublisherWithCompression(pub, compression.Zstd, 0).
+		Publish(context.Background(), WithPayload("test", text))
+	assert.NoError(t, err)
+	if assert.Len(t, pub.messages, 1) {
+		data := pub.messages[0].(json.RawMessage)
+		assert.Contains(t, string(data), `"compression":"zstd"`)
+
+		var ev event
+		assert.NoError(t, ev.Decode(data))
+		assert.NoError(t, ev.Payload().Decode(&res))
+		assert.Equal(t, text, res)
+	}
+}
+
+func TestEventEncryption(t *testing.T) {
+	var (
+		res  string
+		key1 = []byte("0123456789abcdef")
+		key2 = []byte("fedcba9876543210")
+		enc1 = encryption.NewAESGCM(encryption.NewStaticKeyProvider("k1", map[string][]byte{"k1": key1}))
+		enc2 = encryption.NewAESGCM(encryption.NewStaticKeyProvider("k2", map[string][]byte{"k1": key1, "k2": key2}))
+		mux1 = NewTaskMux(WithEncryption(enc1), WithCompression(compression.Snappy, 0))
+		mux2 = NewTaskMux(WithEncryption(enc2))
+		ev   = WithPayload("test", "personal data")
+	)
+	ev.SetMux(mux1)
+	data, err := ev.Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"key_id":"k1"`)
+
+	mux2.Handle("test", func(s string) { res = s })
+	assert.NoError(t, mux2.Receive(message(data)))
+	assert.Equal(t, "personal data", res)
+
+	assert.ErrorIs(t, NewTaskMux().Receive(message(data)), ErrEncryptorUndefined)
+
+	// Encrypted payload is bound to the event name
+	mux2.Handle("other", func(s string) { res = s })
+	data = []byte(strings.Replace(string(data), `"name":"test"`, `"name":"other"`, 1))
+	assert.ErrorIs(t, mux2.Receive(message(data)), encryption.ErrInvalidMessage)
+}
+
+func TestEventClaimCheck(t *testing.T) {
+	var (
+		res   string
+		text  = strings.Repeat("claim-check ", 10)
+		store = blobstore.NewMemory()
+		mux   = NewTaskMux(WithClaimCheck(store, 100, time.Minute), WithCompletionStore(monitor.NewMemoryCompletionStore(0)))
+		ev    = WithPayload("test", text)
+	)
+	ev.SetMux(mux)
+	data, err := ev.Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"payload_ref"`)
+	assert.NotContains(t, string(data), `"payload":`)
+	assert.Equal(t, 1, store.Len())
+
+	// Forwarded reference is sent without the new blob
+	var fwd event
+	assert.NoError(t, fwd.decode(data, mux))
+	fwd.SetMux(mux)
+	fwdData, err := fwd.Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+
+	// Blobs are kept until the end of the whole chain
+	var blobs int
+	mux.Handle("test", func(s string) string { return s + "!" }).
+		Then(func(s string) { res, blobs = s, store.Len() })
+	assert.NoError(t, mux.Receive(message(fwdData)))
+	assert.Equal(t, text+"!", res)
+	assert.Equal(t, 1, blobs)
+	assert.Equal(t, 0, store.Len(), "blobs of the chain must be removed after the chain end")
+
+	// Without the completion tracking blobs are removed by TTL
+	mux = NewTaskMux(WithClaimCheck(store, 100, 0))
+	mux.Handle("test", func(s string) { res = s })
+	ev.SetMux(mux)
+	data, err = ev.Encode()
+	assert.NoError(t, err)
+	assert.NoError(t, mux.Receive(message(data)))
+	assert.Equal(t, text, res)
+	assert.Equal(t, 1, store.Len())
+
+	assert.ErrorIs(t, NewTaskMux().Receive(message(data)), ErrBlobStoreUndefined)
+}
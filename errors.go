@@ -1,6 +1,13 @@
 package asyncp
 
-import "github.com/demdxx/asyncp/v2/libs/errors"
+import (
+	"strings"
+
+	"github.com/demdxx/asyncp/v2/libs/errors"
+)
+
+// errorCodeValidation of the envelope marks the payload rejected by the schema
+const errorCodeValidation = "validation"
 
 var (
 	// ErrSkipEvent in case of repeat count exceeds the limit
@@ -8,6 +15,9 @@ var (
 
 	// ErrNil in case of empty response
 	ErrNil = errors.ErrNil
+
+	// ErrValidation in case of payload doesn't match the schema
+	ErrValidation = errors.ErrValidation
 )
 
 func errorString(err error) string {
@@ -17,3 +27,21 @@ func errorString(err error) string {
 func stringError(msg string) error {
 	return errors.StringError(msg)
 }
+
+// errorCode of the envelope to restore the type of the error after the transport
+func errorCode(err error) string {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return errorCodeValidation
+	}
+	return ``
+}
+
+// codeError restores the error of the envelope by its code
+func codeError(msg, code string) error {
+	if code == errorCodeValidation && msg != `` {
+		list := strings.TrimPrefix(msg, ErrValidation.Error()+": ")
+		return &ValidationError{Errors: strings.Split(list, "; ")}
+	}
+	return stringError(msg)
+}
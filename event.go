@@ -5,9 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2/codec"
 )
 
 // Event provides interface of working with message streams
@@ -56,6 +62,15 @@ type Event interface {
 	// IsComplete returns marker of event completion
 	IsComplete() bool
 
+	// SetReply defines the request correlation ID and the reply event name
+	SetReply(correlationID, replyTo string)
+
+	// CorrelationID of the request which waits for the chain result
+	CorrelationID() string
+
+	// ReplyTo returns the event name of the chain result
+	ReplyTo() string
+
 	// Counters returns current counter state
 	Counters() (sent, retranslated int)
 
@@ -89,8 +104,18 @@ type event struct {
 	payload          Payload
 	sendCount        int
 	retranslateCount int
+	payloadVersion   int
+	correlationID    string
+	replyTo          string
 	err              error
 	createdAt        time.Time
+
+	// refs counter of the pooled event users
+	refs int32
+	pool *sync.Pool
+
+	// measured shows that the payload sizes are sent to the monitor on the next encoding
+	measured int32
 }
 
 // WithPayload returns new event object with payload data
@@ -122,25 +147,37 @@ func WithPayload(eventName string, data any) Event {
 }
 
 func (ev *event) String() string {
-	data, _ := ev.Encode()
+	data, _ := ev.encode(nil)
 	return string(data)
 }
 
 // Copy event object
 func (ev *event) Copy() *event {
-	return &event{
-		complete:         ev.complete,
-		id:               ev.id,
-		name:             ev.name,
-		doneEvents:       append(make([]string, 0, len(ev.doneEvents)), ev.doneEvents...),
-		mux:              ev.mux,
-		promise:          ev.promise,
-		payload:          ev.payload,
-		sendCount:        ev.sendCount,
-		retranslateCount: ev.retranslateCount,
-		err:              ev.err,
-		createdAt:        time.Now(),
+	newEvent := &event{}
+	ev.copyTo(newEvent)
+	return newEvent
+}
+
+// copyTo the target event object with reusing of its buffers
+func (ev *event) copyTo(target *event) {
+	doneEvents := target.doneEvents[:0]
+	if doneEvents == nil {
+		doneEvents = make([]string, 0, len(ev.doneEvents))
 	}
+	target.complete = ev.complete
+	target.id = ev.id
+	target.name = ev.name
+	target.doneEvents = append(doneEvents, ev.doneEvents...)
+	target.mux = ev.mux
+	target.promise = ev.promise
+	target.payload = ev.payload
+	target.sendCount = ev.sendCount
+	target.retranslateCount = ev.retranslateCount
+	target.payloadVersion = ev.payloadVersion
+	target.correlationID = ev.correlationID
+	target.replyTo = ev.replyTo
+	target.err = ev.err
+	target.createdAt = time.Now()
 }
 
 // ID returns the UUID value
@@ -198,15 +235,18 @@ func (ev *event) WithName(name string) Event {
 // WithPayload returns new event object with extended payload context
 func (ev *event) WithPayload(data any) Event {
 	newEvent := ev.Copy()
-	newEvent.err = nil
+	newEvent.setPayload(data)
+	return newEvent
+}
+
+func (ev *event) setPayload(data any) {
+	ev.err = nil
+	ev.payloadVersion = 0
 	if payload, ok := data.(Payload); ok {
-		newEvent.payload = payload
+		ev.payload = payload
 	} else {
-		payload, err := newPayload(data)
-		newEvent.payload = payload
-		newEvent.err = err
+		ev.payload, ev.err = newPayload(data)
 	}
-	return newEvent
 }
 
 // Counters returns current counter state
@@ -256,6 +296,22 @@ func (ev *event) IsComplete() bool {
 	return ev.complete
 }
 
+// SetReply defines the request correlation ID and the reply event name
+func (ev *event) SetReply(correlationID, replyTo string) {
+	ev.correlationID = correlationID
+	ev.replyTo = replyTo
+}
+
+// CorrelationID of the request which waits for the chain result
+func (ev *event) CorrelationID() string {
+	return ev.correlationID
+}
+
+// ReplyTo returns the event name of the chain result
+func (ev *event) ReplyTo() string {
+	return ev.replyTo
+}
+
 // DoneTasks returns the list of previous event names
 func (ev *event) DoneTasks() []string {
 	return ev.doneEvents
@@ -272,69 +328,183 @@ func (ev *event) HasDoneTask(name string) bool {
 }
 
 type encodeEvent struct {
-	ID               uuid.UUID `json:"id"`
-	Name             string    `json:"name"`
-	Payload          []byte    `json:"payload,omitempty"`
-	DoneEvents       []string  `json:"evdone,omitempty"`
-	SendCount        int       `json:"send_count,omitempty"`
-	RetranslateCount int       `json:"retranslate_count,omitempty"`
-	Err              string    `json:"error,omitempty"`
-	CreatedAt        time.Time `json:"created_at"`
+	Version          int             `json:"version,omitempty"`
+	ID               uuid.UUID       `json:"id"`
+	Name             string          `json:"name"`
+	Payload          []byte          `json:"payload,omitempty"`
+	Data             json.RawMessage `json:"data,omitempty"`
+	ContentType      string          `json:"content_type,omitempty"`
+	Compression      string          `json:"compression,omitempty"`
+	KeyID            string          `json:"key_id,omitempty"`
+	PayloadRef       string          `json:"payload_ref,omitempty"`
+	PayloadVersion   int             `json:"payload_version,omitempty"`
+	CorrelationID    string          `json:"correlation_id,omitempty"`
+	ReplyTo          string          `json:"reply_to,omitempty"`
+	Complete         bool            `json:"complete,omitempty"`
+	DoneEvents       []string        `json:"evdone,omitempty"`
+	SendCount        int             `json:"send_count,omitempty"`
+	RetranslateCount int             `json:"retranslate_count,omitempty"`
+	Err              string          `json:"error,omitempty"`
+	ErrCode          string          `json:"error_code,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// markPublished to measure the payload once on the encoding for the stream
+func (ev *event) markPublished() {
+	atomic.StoreInt32(&ev.measured, 1)
 }
 
 // Encode event to byte array
 func (ev *event) Encode() ([]byte, error) {
+	return ev.encode(ev.mux.envelope())
+}
+
+func (ev *event) encode(opts *envelopeOptions) ([]byte, error) {
+	item, err := ev.toEnvelope(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.isCloudEvents() {
+		return encodeCloudEvent(item, opts.cloudEvents)
+	}
+	if item.Version >= 2 && isJSONData(item) {
+		// Avoid base64 encoding of the JSON payloads
+		item.Data, item.Payload = json.RawMessage(item.Payload), nil
+	}
+	var buff bytes.Buffer
+	if err = json.NewEncoder(&buff).Encode(item); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// toEnvelope returns the envelope of the event with the encoded payload
+func (ev *event) toEnvelope(opts *envelopeOptions) (*encodeEvent, error) {
 	var (
-		data []byte
 		err  error
-		buff bytes.Buffer
+		item = &encodeEvent{
+			Version:          opts.version(),
+			ID:               ev.id,
+			Name:             ev.name,
+			PayloadVersion:   ev.mux.payloadVersion(ev),
+			DoneEvents:       ev.doneEvents,
+			SendCount:        ev.sendCount,
+			RetranslateCount: ev.retranslateCount,
+			Complete:         ev.complete,
+			CorrelationID:    ev.correlationID,
+			ReplyTo:          ev.replyTo,
+			Err:              errorString(ev.err),
+			ErrCode:          errorCode(ev.err),
+			CreatedAt:        ev.createdAt,
+		}
 	)
-	if ev.payload != nil {
-		if data, err = ev.payload.Encode(); err != nil {
+	if bp, _ := ev.payload.(*blobPayload); bp != nil && bp.canForward(ev, opts) {
+		// Send the same reference without blob fetching
+		item.PayloadRef, item.ContentType, item.Compression, item.KeyID = bp.ref, bp.contentType, bp.compression, bp.keyID
+		item.PayloadVersion = bp.version
+	} else if ev.payload != nil {
+		if item.Payload, err = ev.payload.Encode(); err != nil {
 			return nil, err
 		}
+		item.ContentType = payloadContentType(ev.payload)
+		rawSize := len(item.Payload)
+		if item.Payload, item.Compression, err = opts.compress(item.Payload); err != nil {
+			return nil, err
+		}
+		if opts.isMeasured() && atomic.CompareAndSwapInt32(&ev.measured, 1, 0) {
+			ev.mux.encodedPayload(ev, rawSize, len(item.Payload))
+		}
+		if item.Payload, item.KeyID, err = opts.encrypt(item.Payload, ev.id, ev.name); err != nil {
+			return nil, err
+		}
+		if opts.isClaimCheck(len(item.Payload)) {
+			if item.PayloadRef, err = opts.putBlob(ev.id, item.Payload); err != nil {
+				return nil, err
+			}
+			item.Payload = nil
+		}
 	}
-	err = json.NewEncoder(&buff).Encode(&encodeEvent{
-		ID:               ev.id,
-		Name:             ev.name,
-		Payload:          data,
-		DoneEvents:       ev.doneEvents,
-		SendCount:        ev.sendCount,
-		RetranslateCount: ev.retranslateCount,
-		Err:              errorString(err),
-		CreatedAt:        ev.createdAt,
-	})
-	if err != nil {
-		return nil, err
-	}
-	return buff.Bytes(), nil
+	return item, nil
 }
 
 // Decode event by the byte array
 func (ev *event) Decode(data []byte) error {
+	return ev.decode(data, ev.mux)
+}
+
+func (ev *event) decode(data []byte, mux *TaskMux) error {
+	item := envelopePool.Get().(*encodeEvent)
+	defer func() {
+		*item = encodeEvent{}
+		envelopePool.Put(item)
+	}()
+	if isCloudEvent(data) {
+		if err := decodeCloudEvent(data, item); err != nil {
+			return err
+		}
+	} else {
+		// Reuse buffer of the done events of the pooled event
+		item.DoneEvents = ev.doneEvents[:0]
+		if err := json.Unmarshal(data, item); err != nil {
+			return errors.Wrap(err, `decode event envelope`)
+		}
+		if item.Version > envelopeVersion {
+			return errors.Wrap(ErrUnsupportedEnvelopeVersion, strconv.Itoa(item.Version))
+		}
+		if len(item.Data) > 0 {
+			item.Payload = item.Data
+		}
+	}
+	return ev.fromEnvelope(item, mux)
+}
+
+// fromEnvelope fills the event from the envelope with the encoded payload
+func (ev *event) fromEnvelope(item *encodeEvent, mux *TaskMux) error {
 	var (
-		item encodeEvent
-		err  = json.NewDecoder(bytes.NewBuffer(data)).Decode(&item)
+		opts   = mux.envelope()
+		upcast = mux.upcaster(item.Name, item.PayloadVersion)
 	)
-	if err != nil {
-		return nil
+	payloadCodec, err := codec.Lookup(item.ContentType)
+	switch {
+	case err != nil:
+	case item.PayloadRef != ``:
+		// Payload stored in the blob store will be fetched on the first access
+		var blob *blobPayload
+		if blob, err = newBlobPayload(item.PayloadRef, item, payloadCodec, opts); err == nil {
+			blob.upcast = upcast
+			ev.payload = blob
+		}
+	default:
+		if item.Payload, err = opts.decrypt(item.Payload, item.KeyID, item.ID, item.Name); err == nil {
+			item.Payload, err = decompressPayload(item.Payload, item.Compression)
+		}
+		if err == nil && upcast != nil {
+			item.Payload, err = upcast(item.Payload, codec.Or(payloadCodec))
+		}
+		ev.payload = dataPayload{bytes: item.Payload, codec: payloadCodec}
 	}
 	ev.id = item.ID
 	ev.name = item.Name
-	ev.payload, err = newPayload(item.Payload)
-	ev.err = stringError(item.Err)
+	ev.doneEvents = item.DoneEvents
+	ev.sendCount = item.SendCount
+	ev.retranslateCount = item.RetranslateCount
+	ev.complete = item.Complete
+	ev.correlationID = item.CorrelationID
+	ev.replyTo = item.ReplyTo
+	ev.payloadVersion = max(item.PayloadVersion, mux.PayloadVersion(item.Name))
+	ev.err = codeError(item.Err, item.ErrCode)
 	ev.createdAt = item.CreatedAt
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
 
 // Clear event object
 func (ev *event) Clear() {
-	ev.name = ""
-	ev.payload = nil
-	ev.err = nil
+	*ev = event{pool: ev.pool}
+}
+
+// reset the pooled event and keep the buffer of the done events for the next decoding
+func (ev *event) reset() {
+	*ev = event{doneEvents: ev.doneEvents[:0], pool: ev.pool}
 }
 
 // UnmarshalJSON implements and wraps json.Unmarshaler interface
@@ -10,6 +10,10 @@ type EventAllocator interface {
 
 type defaultEventAllocator struct {
 	pool sync.Pool
+	mux  *TaskMux
+
+	// cloudEventsOnly rejects messages in any other format
+	cloudEventsOnly bool
 }
 
 func newDefaultEventAllocator() *defaultEventAllocator {
@@ -20,16 +24,43 @@ func newDefaultEventAllocator() *defaultEventAllocator {
 	}
 }
 
+func (a *defaultEventAllocator) SetMux(mux *TaskMux) {
+	a.mux = mux
+}
+
+// NewCloudEventsAllocator returns event allocator which accepts
+// messages in CloudEvents structured and binary modes only
+func NewCloudEventsAllocator() EventAllocator {
+	allocator := newDefaultEventAllocator()
+	allocator.cloudEventsOnly = true
+	return allocator
+}
+
 func (a *defaultEventAllocator) Decode(msg Message) (Event, error) {
-	event := &event{}
-	return event, event.Decode(msg.Body())
+	event := a.newEvent()
+	if hmsg, ok := msg.(HeaderMessage); ok {
+		if err := event.decodeCloudEventBinary(hmsg.Headers(), msg.Body(), a.mux); err != ErrNotCloudEvent {
+			return event, err
+		}
+	}
+	if a.cloudEventsOnly && !isCloudEvent(msg.Body()) {
+		return event, ErrNotCloudEvent
+	}
+	return event, event.decode(msg.Body(), a.mux)
+}
+
+// newEvent returns the event from the pool if the pooling is enabled by the mux
+func (a *defaultEventAllocator) newEvent() *event {
+	if a.mux != nil && a.mux.eventPooling {
+		return acquireEvent(&a.pool)
+	}
+	return &event{}
 }
 
+// Release the event, it returns to the pool after the release of all references
 func (a *defaultEventAllocator) Release(e Event) error {
 	if e != nil {
-		ev := e.(*event)
-		ev.Clear()
-		a.pool.Put(ev)
+		ReleaseEvent(e)
 	}
 	return nil
 }
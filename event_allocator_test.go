@@ -14,3 +14,75 @@ func TestDefaultAllocator(t *testing.T) {
 		assert.NoError(t, a.Release(e))
 	}
 }
+
+func benchmarkEnvelope(b *testing.B, opts ...Option) []byte {
+	ev := WithPayload("test", map[string]any{"text": "message", "count": 100, "tags": []string{"a", "b", "c"}})
+	ev.SetMux(NewTaskMux(opts...))
+	data, err := ev.Encode()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkAllocatorDecode(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "base64", data: benchmarkEnvelope(b, WithEnvelopeVersion(1))},
+		{name: "raw", data: benchmarkEnvelope(b, WithEnvelopeVersion(2))},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var (
+				a   = newDefaultEventAllocator()
+				msg = message(bench.data)
+				res map[string]any
+			)
+			a.SetMux(NewTaskMux(WithEventPooling()))
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bench.data)))
+			for b.Loop() {
+				ev, err := a.Decode(msg)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err = ev.Payload().Decode(&res); err != nil {
+					b.Fatal(err)
+				}
+				_ = a.Release(ev)
+			}
+		})
+	}
+}
+
+func TestEventPoolRefs(t *testing.T) {
+	a := newDefaultEventAllocator()
+	a.SetMux(NewTaskMux(WithEventPooling()))
+	data, err := WithPayload("test", "message").Encode()
+	assert.NoError(t, err)
+	e, err := a.Decode(message(data))
+	assert.NoError(t, err)
+
+	// Retained event is not cleared after the release by the allocator
+	RetainEvent(e)
+	assert.NoError(t, a.Release(e))
+	assert.Equal(t, "test", e.Name())
+	ReleaseEvent(e)
+	assert.Empty(t, e.Name())
+	assert.Panics(t, func() { ReleaseEvent(e) })
+
+	// Not pooled events are ignored
+	ev := WithPayload("test", nil)
+	RetainEvent(ev)
+	ReleaseEvent(ev)
+	assert.Equal(t, "test", ev.Name())
+
+	// Events are not reused without the pooling option
+	a = newDefaultEventAllocator()
+	a.SetMux(NewTaskMux())
+	e, err = a.Decode(message(data))
+	assert.NoError(t, err)
+	assert.NoError(t, a.Release(e))
+	assert.Equal(t, "test", e.Name())
+}
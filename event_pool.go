@@ -0,0 +1,51 @@
+package asyncp
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// eventPool of the internal response events
+	eventPool = sync.Pool{New: func() any { return new(event) }}
+
+	// envelopePool of the decoding buffers
+	envelopePool = sync.Pool{New: func() any { return new(encodeEvent) }}
+)
+
+// acquireEvent returns event from the pool with one reference
+func acquireEvent(pool *sync.Pool) *event {
+	ev := pool.Get().(*event)
+	ev.pool = pool
+	ev.refs = 1
+	return ev
+}
+
+// RetainEvent prevents reusing of the event after the end of the task execution.
+// Every RetainEvent call must be followed by ReleaseEvent.
+//
+// If the event pooling is enabled, events received from the stream are returned
+// to the pool after the processing, so any goroutine which uses the event
+// after the handler returns must retain it.
+func RetainEvent(ev Event) Event {
+	if e, _ := ev.(*event); e != nil && e.pool != nil {
+		atomic.AddInt32(&e.refs, 1)
+	}
+	return ev
+}
+
+// ReleaseEvent returns the event to the pool if it's not used anymore
+func ReleaseEvent(ev Event) {
+	e, _ := ev.(*event)
+	if e == nil || e.pool == nil {
+		return
+	}
+	switch refs := atomic.AddInt32(&e.refs, -1); {
+	case refs == 0:
+		pool := e.pool
+		e.reset()
+		pool.Put(e)
+	case refs < 0:
+		panic("asyncp: event released more times than retained")
+	}
+}
@@ -1,10 +1,13 @@
 package asyncp
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/codec"
 )
 
 func TestEventMethods(t *testing.T) {
@@ -29,3 +32,191 @@ func TestEventAfter(t *testing.T) {
 
 	assert.ElementsMatch(t, []string{`test1`, `test2`}, event3.DoneTasks())
 }
+
+func TestEventCodec(t *testing.T) {
+	type item struct {
+		Text string
+	}
+	data, err := WithPayload(`test`, PayloadWithCodec(&item{Text: `gob`}, codec.Gob)).Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"content_type":"application/x-gob"`)
+
+	var (
+		ev  event
+		res item
+	)
+	assert.NoError(t, ev.Decode(data))
+	assert.NoError(t, ev.Payload().Decode(&res))
+	assert.Equal(t, `gob`, res.Text)
+
+	assert.ErrorIs(t, ev.Decode([]byte(`{"name":"test","content_type":"application/unknown"}`)),
+		codec.ErrUnsupportedContentType)
+}
+
+func TestEventUpcast(t *testing.T) {
+	type orderV0 struct {
+		Price int `json:"price"`
+	}
+	type orderV1 struct {
+		Amount int `json:"amount"`
+	}
+	type orderV2 struct {
+		Amount   int    `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	var (
+		res orderV2
+		mux = NewTaskMux()
+	)
+	mux.Upcast("order", 0, UpcastType(func(o orderV0) (orderV1, error) {
+		return orderV1{Amount: o.Price}, nil
+	}))
+	mux.Upcast("order", 1, UpcastType(func(o orderV1) (orderV2, error) {
+		return orderV2{Amount: o.Amount, Currency: "USD"}, nil
+	}))
+	mux.Handle("order", func(o orderV2) { res = o })
+	assert.Equal(t, 2, mux.PayloadVersion("order"))
+	assert.Panics(t, func() { mux.Upcast("order", 1, nil) })
+
+	data, err := WithPayload("order", orderV0{Price: 100}).Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"version":1`)
+	assert.NoError(t, mux.Receive(message(data)))
+	assert.Equal(t, orderV2{Amount: 100, Currency: "USD"}, res)
+
+	ev := WithPayload("order", res)
+	ev.SetMux(mux)
+	data, err = ev.Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"payload_version":2`)
+	assert.NoError(t, mux.Receive(message(data)))
+	assert.Equal(t, orderV2{Amount: 100, Currency: "USD"}, res)
+
+	err = mux.Receive(message([]byte(`{"version":100,"name":"order"}`)))
+	assert.ErrorIs(t, err, ErrUnsupportedEnvelopeVersion)
+}
+
+func TestEventRoundTrip(t *testing.T) {
+	var (
+		prev = WithPayload("prev", nil).(*event)
+		ev   = WithPayload("test", map[string]any{"text": "test"}).(*event)
+	)
+	prev.doneEvents = []string{"first"}
+	ev.After(prev)
+	ev.Repeat(ev)
+	ev.SetComplete(true)
+	ev.err = fmt.Errorf("test error")
+
+	data, err := ev.Encode()
+	assert.NoError(t, err)
+
+	var newEv event
+	assert.NoError(t, newEv.Decode(data))
+	assert.Equal(t, ev.ID(), newEv.ID())
+	assert.Equal(t, ev.Name(), newEv.Name())
+	assert.Equal(t, ev.DoneTasks(), newEv.DoneTasks())
+	assert.True(t, newEv.IsComplete())
+	assert.EqualError(t, newEv.Err(), "test error")
+	assert.True(t, ev.CreatedAt().Equal(newEv.CreatedAt()))
+	sent, retranslated := newEv.Counters()
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, 1, retranslated)
+
+	assert.Error(t, newEv.Decode([]byte(`{"id":`)))
+}
+
+func TestEventErrorCode(t *testing.T) {
+	for name, mux := range map[string]*TaskMux{
+		"envelope":    NewTaskMux(),
+		"cloudevents": NewTaskMux(WithCloudEvents(CloudEventsOptions{Source: "/test"})),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ev := WithPayload("test", nil).(*event)
+			ev.SetMux(mux)
+			ev.err = &ValidationError{Errors: []string{"/id: required", "/title: invalid"}}
+			data, err := ev.Encode()
+			assert.NoError(t, err)
+
+			var (
+				newEv event
+				verr  *ValidationError
+			)
+			assert.NoError(t, newEv.Decode(data))
+			if assert.ErrorAs(t, newEv.Err(), &verr) {
+				assert.Equal(t, []string{"/id: required", "/title: invalid"}, verr.Errors)
+			}
+
+			// Errors are classified by the code only
+			ev.err = fmt.Errorf("%s: not a schema error", ErrValidation.Error())
+			data, err = ev.Encode()
+			assert.NoError(t, err)
+			assert.NoError(t, newEv.Decode(data))
+			assert.False(t, errors.As(newEv.Err(), &verr))
+		})
+	}
+}
+
+func TestEventDecodeLegacy(t *testing.T) {
+	type item struct {
+		Text string `json:"text"`
+	}
+	envelopes := map[string]string{
+		// Envelope without versions and content type
+		"v0": `{"id":"b7c8b1de-8f34-4a3e-9e3e-0e5b1c0f7d10","name":"test","payload":"eyJ0ZXh0IjoidGVzdCJ9",` +
+			`"evdone":["a","b"],"send_count":2,"retranslate_count":1,"created_at":"2024-01-02T03:04:05Z"}`,
+		// Envelope with content type and without versions
+		"content_type": `{"id":"b7c8b1de-8f34-4a3e-9e3e-0e5b1c0f7d10","name":"test","payload":"eyJ0ZXh0IjoidGVzdCJ9",` +
+			`"content_type":"application/json","evdone":["a","b"],"send_count":2,"retranslate_count":1,"created_at":"2024-01-02T03:04:05Z"}`,
+		// Envelope with base64 payload
+		"v1": `{"version":1,"id":"b7c8b1de-8f34-4a3e-9e3e-0e5b1c0f7d10","name":"test","payload":"eyJ0ZXh0IjoidGVzdCJ9",` +
+			`"content_type":"application/json","evdone":["a","b"],"send_count":2,"retranslate_count":1,"created_at":"2024-01-02T03:04:05Z"}`,
+	}
+	for name, envelope := range envelopes {
+		t.Run(name, func(t *testing.T) {
+			var (
+				it item
+				ev event
+			)
+			assert.NoError(t, ev.Decode([]byte(envelope)))
+			assert.Equal(t, "b7c8b1de-8f34-4a3e-9e3e-0e5b1c0f7d10", ev.ID().String())
+			assert.Equal(t, "test", ev.Name())
+			assert.Equal(t, []string{"a", "b"}, ev.DoneTasks())
+			assert.True(t, ev.HasDoneTask("b"))
+			sent, retranslated := ev.Counters()
+			assert.Equal(t, 2, sent)
+			assert.Equal(t, 1, retranslated)
+			assert.NoError(t, ev.Payload().Decode(&it))
+			assert.Equal(t, "test", it.Text)
+		})
+	}
+}
+
+func TestEventRawData(t *testing.T) {
+	mux := NewTaskMux(WithEnvelopeVersion(2))
+	ev := WithPayload("test", map[string]any{"text": "test"})
+	ev.SetMux(mux)
+	data, err := ev.Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"data":{"text":"test"}`)
+	assert.NotContains(t, string(data), `"payload":`)
+
+	// Not JSON payloads are still base64 encoded
+	raw := WithPayload("test", []byte{0xff, 0x01})
+	raw.SetMux(mux)
+	data, err = raw.Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"payload":"/wE="`)
+
+	var newEv event
+	assert.NoError(t, newEv.Decode(data))
+	res, err := newEv.Payload().Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0x01}, res)
+
+	// The base64 envelope is produced by default
+	ev.SetMux(NewTaskMux())
+	data, err = ev.Encode()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"version":1`)
+	assert.Contains(t, string(data), `"payload":"eyJ0ZXh0IjoidGVzdCJ9"`)
+}
@@ -36,37 +36,30 @@ type rss struct {
 
 var loadCounter int
 
-func downloadRSSList(ctx context.Context, event asyncp.Event, responseWriter asyncp.ResponseWriter) error {
-	fmt.Println("downloadProxyList", event)
-	var rsslink string
-	if err := event.Payload().Decode(&rsslink); err != nil {
-		return err
-	}
+func downloadRSSList(ctx context.Context, rsslink string) ([]rssItem, error) {
+	fmt.Println("downloadProxyList", rsslink)
 	res, err := http.Get(rsslink)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = res.Body.Close() }()
 	data, err := io.ReadAll(res.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var rss rss
 	if err = xml.Unmarshal(data, &rss); err != nil {
-		return err
+		return nil, err
 	}
+	var items []rssItem
 	for _, channel := range rss.Channel {
 		fmt.Println("Chanel:     ", channel.Title, channel.Language)
 		fmt.Println("Link:       ", channel.Link)
 		fmt.Println("Description:", channel.Description)
-		for _, item := range channel.Item {
-			err := responseWriter.WriteResonse(item)
-			if err != nil {
-				return err
-			}
-		}
+		// Every item will be sent as separate event
+		items = append(items, channel.Item...)
 	}
-	return nil
+	return items, nil
 }
 
 func downloadRSSItem(ctx context.Context, event asyncp.Event, responseWriter asyncp.ResponseWriter) error {
@@ -115,7 +108,7 @@ func main() {
 	proxy := asyncp.NewProxySubscriber(mempr)
 
 	mx := asyncp.NewTaskMux(asyncp.WithStreamResponsePublisher(mempr.Publisher()))
-	mx.Handle("rss", downloadRSSList).
+	asyncp.Handle(mx, "rss", downloadRSSList).
 		Then(downloadRSSItem).
 		Then(printResults).
 		Then(closeAction(proxy))
@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DOT returns the graph in Graphviz format.
+// Anonymous steps are rounded, external links are dashed
+// and tasks of the same application are grouped into clusters.
+func (g *Graph) DOT() string {
+	var buf strings.Builder
+	buf.WriteString("digraph tasks {\n  rankdir=LR;\n  node [shape=box];\n")
+	writeNode := func(indent string, nd *Node) {
+		attrs := []string{"label=" + strconv.Quote(nd.ID)}
+		if nd.Anonymous {
+			attrs = append(attrs, `style="rounded,dashed"`)
+		} else if nd.Root {
+			attrs = append(attrs, "style=bold")
+		}
+		fmt.Fprintf(&buf, "%s%s [%s];\n", indent, strconv.Quote(nd.ID), strings.Join(attrs, ", "))
+	}
+	for i, app := range g.apps() {
+		fmt.Fprintf(&buf, "  subgraph cluster_%d {\n    label=%s;\n", i, strconv.Quote(app))
+		for _, nd := range g.Nodes {
+			if nd.App == app {
+				writeNode("    ", nd)
+			}
+		}
+		buf.WriteString("  }\n")
+	}
+	for _, nd := range g.Nodes {
+		if nd.App == "" {
+			writeNode("  ", nd)
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&buf, "  %s -> %s", strconv.Quote(e.From), strconv.Quote(e.To))
+		if e.External {
+			buf.WriteString(" [style=dashed]")
+		}
+		buf.WriteString(";\n")
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// Mermaid returns the graph in Mermaid flowchart format
+func (g *Graph) Mermaid() string {
+	var (
+		buf strings.Builder
+		ids = make(map[string]string, len(g.Nodes))
+	)
+	for i, nd := range g.Nodes {
+		ids[nd.ID] = "n" + strconv.Itoa(i)
+	}
+	writeNode := func(indent string, nd *Node) {
+		label := strings.ReplaceAll(nd.ID, `"`, "#quot;")
+		if nd.Anonymous {
+			fmt.Fprintf(&buf, "%s%s([\"%s\"])\n", indent, ids[nd.ID], label)
+		} else {
+			fmt.Fprintf(&buf, "%s%s[\"%s\"]\n", indent, ids[nd.ID], label)
+		}
+	}
+	buf.WriteString("flowchart LR\n")
+	for _, app := range g.apps() {
+		fmt.Fprintf(&buf, "  subgraph %s\n", strings.ReplaceAll(app, " ", "_"))
+		for _, nd := range g.Nodes {
+			if nd.App == app {
+				writeNode("    ", nd)
+			}
+		}
+		buf.WriteString("  end\n")
+	}
+	for _, nd := range g.Nodes {
+		if nd.App == "" {
+			writeNode("  ", nd)
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.External {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&buf, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+	return buf.String()
+}
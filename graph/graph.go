@@ -0,0 +1,153 @@
+// Package graph builds the task graph of the mux or the whole cluster
+// and exports it into Graphviz DOT, Mermaid and JSON formats.
+package graph
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+// Node of the task graph
+type Node struct {
+	ID        string `json:"id"`
+	App       string `json:"app,omitempty"`
+	Anonymous bool   `json:"anonymous,omitempty"` // Generated step of the chain `name.N`
+	Chain     string `json:"chain,omitempty"`     // Name of the chain of the anonymous step
+	Root      bool   `json:"root,omitempty"`      // Entry point without incoming links
+	External  bool   `json:"external,omitempty"`  // Task is not defined in the graph, but linked by `@name`
+
+	// Apps defining the task in the cluster
+	Apps []string `json:"apps,omitempty"`
+
+	InputSchema  json.RawMessage `json:"input_schema,omitempty"`
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"`
+}
+
+// Edge between tasks of the graph
+type Edge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	External bool   `json:"external,omitempty"` // Link by `@name` which is resolved in the cluster
+}
+
+// Graph of the tasks
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+}
+
+// New graph from the task map, where the key is event name
+// and the value is the list of target events
+func New(tasks map[string][]string) *Graph {
+	return FromApplication(&monitor.ApplicationInfo{Tasks: tasks})
+}
+
+// FromApplication returns graph of the application or merged cluster information
+func FromApplication(info *monitor.ApplicationInfo) *Graph {
+	g := &Graph{}
+	if info == nil {
+		return g
+	}
+	nodes := map[string]*Node{}
+	node := func(name string) *Node {
+		if nd := nodes[name]; nd != nil {
+			return nd
+		}
+		nd := &Node{ID: name, Root: true}
+		nd.Chain, nd.Anonymous = anonymousChain(name)
+		if sch := info.Schemas[name]; sch != nil {
+			nd.App, nd.InputSchema, nd.OutputSchema = sch.App, sch.Input, sch.Output
+		}
+		if nd.Apps = info.TaskApps[name]; nd.App == "" && len(nd.Apps) > 0 {
+			nd.App = nd.Apps[0]
+		}
+		nodes[name] = nd
+		return nd
+	}
+	for eventName, targets := range info.Tasks {
+		var (
+			name     = strings.TrimLeft(eventName, "@")
+			external = name != eventName
+		)
+		if name == "" {
+			continue
+		}
+		// External hooks `@name` don't define the task itself
+		if !external || len(targets) > 0 {
+			node(name)
+		}
+		for _, target := range targets {
+			if target == "" {
+				continue
+			}
+			node(target).Root = false
+			g.addEdge(&Edge{From: name, To: target, External: external})
+		}
+	}
+	for _, nd := range nodes {
+		_, defined := info.Tasks[nd.ID]
+		nd.External = !defined
+		g.Nodes = append(g.Nodes, nd)
+	}
+	slices.SortFunc(g.Nodes, func(a, b *Node) int { return strings.Compare(a.ID, b.ID) })
+	slices.SortFunc(g.Edges, func(a, b *Edge) int {
+		if c := strings.Compare(a.From, b.From); c != 0 {
+			return c
+		}
+		return strings.Compare(a.To, b.To)
+	})
+	return g
+}
+
+// Node returns node by ID
+func (g *Graph) Node(id string) *Node {
+	for _, nd := range g.Nodes {
+		if nd.ID == id {
+			return nd
+		}
+	}
+	return nil
+}
+
+// JSON representation of the graph
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+func (g *Graph) addEdge(edge *Edge) {
+	for _, e := range g.Edges {
+		if e.From == edge.From && e.To == edge.To {
+			e.External = e.External && edge.External
+			return
+		}
+	}
+	g.Edges = append(g.Edges, edge)
+}
+
+// apps returns the list of application names of the graph
+func (g *Graph) apps() []string {
+	var apps []string
+	for _, nd := range g.Nodes {
+		if nd.App != "" && !slices.Contains(apps, nd.App) {
+			apps = append(apps, nd.App)
+		}
+	}
+	slices.Sort(apps)
+	return apps
+}
+
+// anonymousChain returns the chain name of the `name.N` steps
+func anonymousChain(name string) (string, bool) {
+	idx := strings.LastIndexByte(name, '.')
+	if idx <= 0 {
+		return "", false
+	}
+	if _, err := strconv.Atoi(name[idx+1:]); err != nil {
+		return "", false
+	}
+	return name[:idx], true
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+func TestGraph(t *testing.T) {
+	g := FromApplication(&monitor.ApplicationInfo{
+		Tasks: map[string][]string{
+			"rss":    {"rss.1"},
+			"rss.1":  {},
+			"@rss.1": {"notify"},
+			"notify": {},
+			"@video": {},
+		},
+		Schemas: map[string]*monitor.TaskSchema{
+			"notify": {App: "mailer", Input: json.RawMessage(`{"type":"string"}`)},
+		},
+	})
+
+	assert.Len(t, g.Nodes, 3)
+	assert.Nil(t, g.Node("video"))
+	assert.True(t, g.Node("rss").Root)
+	assert.True(t, g.Node("rss.1").Anonymous)
+	assert.Equal(t, "rss", g.Node("rss.1").Chain)
+	assert.Equal(t, "mailer", g.Node("notify").App)
+	assert.Equal(t, []*Edge{
+		{From: "rss", To: "rss.1"},
+		{From: "rss.1", To: "notify", External: true},
+	}, g.Edges)
+
+	dot := g.DOT()
+	assert.Contains(t, dot, `subgraph cluster_0 {`)
+	assert.Contains(t, dot, `"rss" -> "rss.1";`)
+	assert.Contains(t, dot, `"rss.1" -> "notify" [style=dashed];`)
+
+	mermaid := g.Mermaid()
+	assert.Contains(t, mermaid, "flowchart LR\n")
+	assert.Contains(t, mermaid, `n2(["rss.1"])`)
+	assert.Contains(t, mermaid, "n2 -.-> n0")
+
+	data, err := g.JSON()
+	assert.NoError(t, err)
+	var res Graph
+	assert.NoError(t, json.Unmarshal(data, &res))
+	assert.Equal(t, g.Edges, res.Edges)
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severity of the graph diagnostic
+type Severity int
+
+// Severity list...
+const (
+	// SeverityWarning can be an expected state, like the link to the task of other application
+	SeverityWarning Severity = iota
+	// SeverityError makes the graph invalid
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// DiagnosticKind of the graph problem
+type DiagnosticKind string
+
+// Diagnostic kinds...
+const (
+	DiagnosticCycle              DiagnosticKind = "cycle"
+	DiagnosticDanglingParent     DiagnosticKind = "dangling_parent"
+	DiagnosticNoEntryPoint       DiagnosticKind = "no_entry_point"
+	DiagnosticDuplicateAnonymous DiagnosticKind = "duplicate_anonymous"
+)
+
+// Diagnostic of the graph problem
+type Diagnostic struct {
+	Kind     DiagnosticKind `json:"kind"`
+	Severity Severity       `json:"severity"`
+	Tasks    []string       `json:"tasks"`
+	Message  string         `json:"message"`
+	Cluster  bool           `json:"cluster,omitempty"` // Found in the merged cluster graph
+}
+
+func (d Diagnostic) String() string {
+	scope := ""
+	if d.Cluster {
+		scope = "cluster "
+	}
+	return fmt.Sprintf("%s%s: %s [%s]", scope, d.Severity, d.Message, strings.Join(d.Tasks, ", "))
+}
+
+// Diagnostics list of the graph
+type Diagnostics []Diagnostic
+
+// HasErrors returns true if any diagnostic has error severity
+func (ds Diagnostics) HasErrors() bool {
+	return slices.ContainsFunc(ds, func(d Diagnostic) bool { return d.Severity == SeverityError })
+}
+
+// Err returns error with all error diagnostics or nil
+func (ds Diagnostics) Err() error {
+	var errs []error
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			errs = append(errs, errors.New(d.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Merge diagnostics without duplicates
+func (ds Diagnostics) Merge(list Diagnostics) Diagnostics {
+	for _, d := range list {
+		if !slices.ContainsFunc(ds, func(it Diagnostic) bool {
+			return it.Kind == d.Kind && slices.Equal(it.Tasks, d.Tasks)
+		}) {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// Validate the graph: cycles, dangling external parents, tasks without entry point
+// and anonymous steps defined by several applications.
+// Dangling parents are warnings, the application which defines them can be not started yet.
+func (g *Graph) Validate(cluster bool) Diagnostics {
+	var ds Diagnostics
+	for _, cycle := range g.cycles() {
+		ds = append(ds, Diagnostic{Kind: DiagnosticCycle, Severity: SeverityError,
+			Tasks: cycle, Message: "tasks are linked in cycle", Cluster: cluster})
+	}
+	for _, nd := range g.Nodes {
+		if nd.External {
+			ds = append(ds, Diagnostic{Kind: DiagnosticDanglingParent, Severity: SeverityWarning,
+				Tasks: []string{nd.ID}, Message: "linked task is not defined", Cluster: cluster})
+		}
+		if nd.Anonymous && len(nd.Apps) > 1 {
+			ds = append(ds, Diagnostic{Kind: DiagnosticDuplicateAnonymous, Severity: SeverityError,
+				Tasks: []string{nd.ID}, Message: "anonymous step is defined by " + strings.Join(nd.Apps, ", "), Cluster: cluster})
+		}
+	}
+	if unreachable := g.unreachable(); len(unreachable) > 0 {
+		ds = append(ds, Diagnostic{Kind: DiagnosticNoEntryPoint, Severity: SeverityError,
+			Tasks: unreachable, Message: "tasks can't be reached from any entry point", Cluster: cluster})
+	}
+	return ds
+}
+
+// cycles returns strongly connected components of the graph (Tarjan's algorithm)
+func (g *Graph) cycles() [][]string {
+	var (
+		index   = 0
+		indexes = map[string]int{}
+		lowlink = map[string]int{}
+		onStack = map[string]bool{}
+		stack   []string
+		cycles  [][]string
+		connect func(name string)
+	)
+	connect = func(name string) {
+		indexes[name], lowlink[name] = index, index
+		index++
+		stack = append(stack, name)
+		onStack[name] = true
+		selfLoop := false
+		for _, e := range g.Edges {
+			if e.From != name {
+				continue
+			}
+			selfLoop = selfLoop || e.To == name
+			if _, ok := indexes[e.To]; !ok {
+				connect(e.To)
+				lowlink[name] = min(lowlink[name], lowlink[e.To])
+			} else if onStack[e.To] {
+				lowlink[name] = min(lowlink[name], indexes[e.To])
+			}
+		}
+		if lowlink[name] != indexes[name] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			slices.Sort(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, nd := range g.Nodes {
+		if _, ok := indexes[nd.ID]; !ok {
+			connect(nd.ID)
+		}
+	}
+	return cycles
+}
+
+// unreachable returns tasks which can't be reached from named tasks without incoming links
+// or from the external events
+func (g *Graph) unreachable() []string {
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, e := range g.Edges {
+			if e.From == name {
+				visit(e.To)
+			}
+		}
+	}
+	for _, nd := range g.Nodes {
+		if (nd.Root && !nd.Anonymous) || nd.External {
+			visit(nd.ID)
+		}
+	}
+	var list []string
+	for _, nd := range g.Nodes {
+		if !visited[nd.ID] {
+			list = append(list, nd.ID)
+		}
+	}
+	return list
+}
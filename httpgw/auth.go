@@ -0,0 +1,78 @@
+package httpgw
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnauthorized in case of the request is not authorized
+var ErrUnauthorized = errors.New(`unauthorized`)
+
+// DefaultSignatureHeader of the HMAC request signature
+const DefaultSignatureHeader = "X-Signature"
+
+// AuthFnk checks the request with the read body
+type AuthFnk func(r *http.Request, body []byte) error
+
+// BearerAuth accepts requests with one of the tokens in the Authorization header
+func BearerAuth(tokens ...string) AuthFnk {
+	return func(r *http.Request, _ []byte) error {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			return ErrUnauthorized
+		}
+		for _, t := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return nil
+			}
+		}
+		return ErrUnauthorized
+	}
+}
+
+// HMACAuth accepts requests with the hex encoded HMAC-SHA256 signature of the body
+// in the header like `X-Signature: sha256=...`. Several secrets can be used for the rotation.
+func HMACAuth(header string, secrets ...[]byte) AuthFnk {
+	if header == "" {
+		header = DefaultSignatureHeader
+	}
+	return func(r *http.Request, body []byte) error {
+		value := r.Header.Get(header)
+		value = strings.TrimPrefix(value, "sha256=")
+		signature, err := hex.DecodeString(value)
+		if err != nil || len(signature) == 0 {
+			return ErrUnauthorized
+		}
+		for _, secret := range secrets {
+			if hmac.Equal(signature, Sign(secret, body)) {
+				return nil
+			}
+		}
+		return ErrUnauthorized
+	}
+}
+
+// Sign the body by HMAC-SHA256
+func Sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// AnyAuth accepts requests accepted by one of the auth functions
+func AnyAuth(auths ...AuthFnk) AuthFnk {
+	return func(r *http.Request, body []byte) error {
+		for _, auth := range auths {
+			if auth(r, body) == nil {
+				return nil
+			}
+		}
+		return ErrUnauthorized
+	}
+}
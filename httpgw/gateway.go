@@ -0,0 +1,344 @@
+// Package httpgw provides HTTP gateway which turns requests into the events.
+//
+// Routes:
+//
+//	POST /events/{name}  - body is the payload of the event
+//	POST /events         - batch of events `[{"name": "...", "payload": {...}}]`
+//
+// The `wait` query param like `?wait=true` or `?wait=10s` returns the results of the chains.
+//
+// Example:
+//
+//	gw := httpgw.New(
+//	  httpgw.WithPublisher(pub),
+//	  httpgw.WithCaller(asyncp.NewCaller(pub, replies)),
+//	  httpgw.WithAuth(httpgw.BearerAuth(token)),
+//	)
+//	http.Handle("/api/", http.StripPrefix("/api", gw))
+package httpgw
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2"
+	"github.com/demdxx/asyncp/v2/codec"
+)
+
+// DefaultTimeout of the result waiting
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxBodySize of the request
+const DefaultMaxBodySize = 4 << 20
+
+// Errors of the gateway
+var (
+	ErrNoTarget        = errors.New(`publisher or mux is required`)
+	ErrWaitUnsupported = errors.New(`waiting of the result requires caller or mux`)
+	ErrEventNotAllowed = errors.New(`event is not allowed`)
+)
+
+// Item of the batch request
+type Item struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Result of the event processing
+type Result struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	err error
+}
+
+// Response of the gateway
+type Response struct {
+	Events []*Result `json:"events"`
+}
+
+type request struct {
+	name    string
+	payload asyncp.Payload
+}
+
+// Gateway of the HTTP requests to the events
+type Gateway struct {
+	opts    Options
+	handler *http.ServeMux
+}
+
+// New gateway with options
+func New(options ...Option) *Gateway {
+	gw := &Gateway{handler: http.NewServeMux()}
+	for _, opt := range options {
+		opt(&gw.opts)
+	}
+	if gw.opts.Timeout <= 0 {
+		gw.opts.Timeout = DefaultTimeout
+	}
+	if gw.opts.MaxBodySize <= 0 {
+		gw.opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if gw.opts.ErrorLog == nil {
+		gw.opts.ErrorLog = func(eventName string, err error) {
+			log.Printf("gateway event %s: %s", eventName, err.Error())
+		}
+	}
+	gw.handler.HandleFunc("POST /events/{name}", gw.handleEvent)
+	gw.handler.HandleFunc("POST /events", gw.handleBatch)
+	return gw
+}
+
+// ServeHTTP implements http.Handler
+func (gw *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	gw.handler.ServeHTTP(w, r)
+}
+
+func (gw *Gateway) handleEvent(w http.ResponseWriter, r *http.Request) {
+	body, ok := gw.readBody(w, r)
+	if !ok {
+		return
+	}
+	c, err := requestCodec(r)
+	if err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+	var payload asyncp.Payload
+	if len(body) > 0 {
+		payload = asyncp.PayloadWithCodec(body, c)
+	}
+	gw.process(w, r, []request{{name: r.PathValue("name"), payload: payload}})
+}
+
+func (gw *Gateway) handleBatch(w http.ResponseWriter, r *http.Request) {
+	body, ok := gw.readBody(w, r)
+	if !ok {
+		return
+	}
+	var items []Item
+	if err := json.Unmarshal(body, &items); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	reqs := make([]request, 0, len(items))
+	for _, item := range items {
+		req := request{name: item.Name}
+		if len(item.Payload) > 0 {
+			req.payload = asyncp.PayloadWithCodec([]byte(item.Payload), codec.JSON)
+		}
+		reqs = append(reqs, req)
+	}
+	gw.process(w, r, reqs)
+}
+
+// readBody of the request limited by the max size and check the auth
+func (gw *Gateway) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, gw.opts.MaxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+		} else {
+			writeError(w, http.StatusBadRequest, err)
+		}
+		return nil, false
+	}
+	if gw.opts.Auth != nil {
+		if err := gw.opts.Auth(r, body); err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return nil, false
+		}
+	}
+	return body, true
+}
+
+func (gw *Gateway) process(w http.ResponseWriter, r *http.Request, reqs []request) {
+	for _, req := range reqs {
+		if req.name == "" || (len(gw.opts.Events) > 0 && !slices.Contains(gw.opts.Events, req.name)) {
+			writeError(w, http.StatusForbidden, errors.Wrap(ErrEventNotAllowed, req.name))
+			return
+		}
+	}
+	wait, timeout, err := gw.waitParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var results []*Result
+	if wait {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		results, err = gw.call(ctx, reqs)
+	} else {
+		results, err = gw.publish(r.Context(), reqs)
+	}
+	switch {
+	case errors.Is(err, ErrNoTarget), errors.Is(err, ErrWaitUnsupported):
+		writeError(w, http.StatusNotImplemented, err)
+	case err != nil:
+		writeError(w, http.StatusBadGateway, err)
+	case !wait:
+		writeJSON(w, http.StatusAccepted, &Response{Events: results})
+	default:
+		writeJSON(w, resultStatus(results), &Response{Events: results})
+	}
+}
+
+// publish events to the stream or execute them by the mux in background
+func (gw *Gateway) publish(ctx context.Context, reqs []request) ([]*Result, error) {
+	results := make([]*Result, 0, len(reqs))
+	events := make([]asyncp.Event, 0, len(reqs))
+	for _, req := range reqs {
+		ev := asyncp.WithPayload(req.name, req.payload)
+		ev.SetMux(gw.opts.Mux)
+		events = append(events, ev)
+		results = append(results, &Result{ID: ev.ID().String(), Name: req.name})
+	}
+	switch {
+	case gw.opts.Publisher != nil:
+		messages := make([]any, 0, len(events))
+		for _, ev := range events {
+			messages = append(messages, ev)
+		}
+		if err := gw.opts.Publisher.Publish(ctx, messages...); err != nil {
+			return nil, err
+		}
+	case gw.opts.Mux != nil:
+		for _, ev := range events {
+			go func(ev asyncp.Event) {
+				if err := gw.opts.Mux.ExecuteEvent(ev); err != nil {
+					gw.opts.ErrorLog(ev.Name(), err)
+				}
+			}(ev)
+		}
+	default:
+		return nil, ErrNoTarget
+	}
+	return results, nil
+}
+
+// call events and wait for the results of the chains
+func (gw *Gateway) call(ctx context.Context, reqs []request) ([]*Result, error) {
+	futures := make([]*asyncp.Future, 0, len(reqs))
+	for _, req := range reqs {
+		var (
+			fut *asyncp.Future
+			err error
+		)
+		switch {
+		case gw.opts.Caller != nil:
+			fut, err = gw.opts.Caller.Submit(ctx, req.name, req.payload, gw.callOptions()...)
+		case gw.opts.Publisher == nil && gw.opts.Mux != nil:
+			fut = gw.opts.Mux.Submit(ctx, req.name, req.payload, asyncp.CallWithTimeout(gw.opts.Timeout))
+		default:
+			err = ErrWaitUnsupported
+		}
+		if err != nil {
+			return nil, err
+		}
+		futures = append(futures, fut)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*Result, len(futures))
+	for i, fut := range futures {
+		wg.Add(1)
+		go func(i int, fut *asyncp.Future) {
+			defer wg.Done()
+			results[i] = newResult(ctx, reqs[i].name, fut)
+		}(i, fut)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// callOptions of the caller, the mux of the caller is kept if the gateway has no mux
+func (gw *Gateway) callOptions() []asyncp.CallOption {
+	options := []asyncp.CallOption{asyncp.CallWithTimeout(gw.opts.Timeout)}
+	if gw.opts.Mux != nil {
+		options = append(options, asyncp.CallWithMux(gw.opts.Mux))
+	}
+	return options
+}
+
+func (gw *Gateway) waitParams(r *http.Request) (wait bool, timeout time.Duration, err error) {
+	value := r.URL.Query().Get("wait")
+	if value == "" {
+		return false, 0, nil
+	}
+	timeout = gw.opts.Timeout
+	if wait, err = strconv.ParseBool(value); err == nil {
+		return wait, timeout, nil
+	}
+	if timeout, err = time.ParseDuration(value); err != nil {
+		return false, 0, errors.Wrap(err, "invalid wait param")
+	}
+	return true, min(timeout, gw.opts.Timeout), nil
+}
+
+func newResult(ctx context.Context, name string, fut *asyncp.Future) *Result {
+	res := &Result{ID: fut.ID(), Name: name}
+	payload, err := fut.Wait(ctx)
+	if err == nil && payload != nil {
+		var value any
+		err = payload.Decode(&value)
+		res.Result = value
+	}
+	if err != nil {
+		res.Error, res.err = err.Error(), err
+	}
+	return res
+}
+
+// resultStatus of the response, errors of the single event are mapped to the status
+func resultStatus(results []*Result) int {
+	status := http.StatusOK
+	for _, res := range results {
+		switch {
+		case res.err == nil:
+		case len(results) > 1:
+			status = http.StatusMultiStatus
+		case errors.Is(res.err, asyncp.ErrCallTimeout), errors.Is(res.err, context.DeadlineExceeded):
+			status = http.StatusGatewayTimeout
+		default:
+			status = http.StatusUnprocessableEntity
+		}
+	}
+	return status
+}
+
+func requestCodec(r *http.Request) (asyncp.Codec, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return codec.JSON, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Lookup(mediaType)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,144 @@
+package httpgw
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geniusrabbit/notificationcenter/v2/gochan"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+func newUpperMux() *asyncp.TaskMux {
+	mux := asyncp.NewTaskMux()
+	mux.Handle("upper", func(s string) (*string, error) {
+		if s == "" {
+			return nil, errors.New("empty")
+		}
+		s = strings.ToUpper(s)
+		return &s, nil
+	})
+	return mux
+}
+
+func serve(gw http.Handler, req *http.Request) (*httptest.ResponseRecorder, *Response) {
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, req)
+	var resp Response
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, &resp
+}
+
+func TestGatewayMux(t *testing.T) {
+	gw := New(WithMux(newUpperMux()), WithEvents("upper"))
+
+	rec, resp := serve(gw, httptest.NewRequest(http.MethodPost, "/events/upper?wait=1s", strings.NewReader(`"hello"`)))
+	if assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) && assert.Len(t, resp.Events, 1) {
+		assert.NotEmpty(t, resp.Events[0].ID)
+		assert.Equal(t, "HELLO", resp.Events[0].Result)
+	}
+
+	rec, resp = serve(gw, httptest.NewRequest(http.MethodPost, "/events/upper?wait=true", strings.NewReader(`""`)))
+	if assert.Equal(t, http.StatusUnprocessableEntity, rec.Code) && assert.Len(t, resp.Events, 1) {
+		assert.Equal(t, "empty", resp.Events[0].Error)
+	}
+
+	rec, resp = serve(gw, httptest.NewRequest(http.MethodPost, "/events?wait=true",
+		strings.NewReader(`[{"name":"upper","payload":"a"},{"name":"upper","payload":"b"}]`)))
+	if assert.Equal(t, http.StatusOK, rec.Code) && assert.Len(t, resp.Events, 2) {
+		assert.Equal(t, "A", resp.Events[0].Result)
+		assert.Equal(t, "B", resp.Events[1].Result)
+	}
+
+	rec, resp = serve(gw, httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(`"async"`)))
+	if assert.Equal(t, http.StatusAccepted, rec.Code) && assert.Len(t, resp.Events, 1) {
+		assert.NotEmpty(t, resp.Events[0].ID)
+	}
+
+	rec, _ = serve(gw, httptest.NewRequest(http.MethodPost, "/events/lower", strings.NewReader(`"a"`)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(`"a"`))
+	req.Header.Set("Content-Type", "application/unknown")
+	rec, _ = serve(gw, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	// Only the body limit responds with 413
+	limited := New(WithMux(newUpperMux()), WithMaxBodySize(4))
+	rec, _ = serve(limited, httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(`"hello"`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	rec, _ = serve(limited, httptest.NewRequest(http.MethodPost, "/events/upper", errReader{}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestGatewayPublisher(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		requests    = gochan.New(10)
+		replies     = gochan.New(10)
+		mux         = asyncp.NewTaskMux(
+			asyncp.WithStreamResponsePublisher(requests.Publisher()),
+			asyncp.WithReplyPublisher(replies.Publisher()),
+		)
+	)
+	defer cancel()
+	mux.Handle("upper", func(s string) string { return strings.ToUpper(s) })
+	assert.NoError(t, requests.Subscribe(ctx, mux))
+	go func() { _ = requests.Listen(ctx) }()
+
+	caller := asyncp.NewCaller(requests.Publisher(), replies)
+	defer func() { _ = caller.Close() }()
+
+	gw := New(WithPublisher(requests.Publisher()), WithCaller(caller), WithTimeout(time.Second))
+	rec, resp := serve(gw, httptest.NewRequest(http.MethodPost, "/events/upper?wait=true", strings.NewReader(`"hello"`)))
+	if assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) && assert.Len(t, resp.Events, 1) {
+		assert.Equal(t, "HELLO", resp.Events[0].Result)
+	}
+
+	rec, resp = serve(gw, httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(`"hello"`)))
+	if assert.Equal(t, http.StatusAccepted, rec.Code) && assert.Len(t, resp.Events, 1) {
+		assert.NotEmpty(t, resp.Events[0].ID)
+	}
+
+	rec, _ = serve(New(WithPublisher(requests.Publisher())),
+		httptest.NewRequest(http.MethodPost, "/events/upper?wait=true", strings.NewReader(`"hello"`)))
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
+
+func TestGatewayAuth(t *testing.T) {
+	var (
+		secret = []byte("secret")
+		body   = `"hello"`
+		gw     = New(WithMux(newUpperMux()), WithAuth(AnyAuth(BearerAuth("token"), HMACAuth("", secret))))
+	)
+
+	rec, _ := serve(gw, httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer token")
+	rec, _ = serve(gw, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(body))
+	req.Header.Set(DefaultSignatureHeader, "sha256="+hex.EncodeToString(Sign(secret, []byte(body))))
+	rec, _ = serve(gw, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/events/upper", strings.NewReader(body))
+	req.Header.Set(DefaultSignatureHeader, "sha256="+hex.EncodeToString(Sign([]byte("other"), []byte(body))))
+	rec, _ = serve(gw, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
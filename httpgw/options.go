@@ -0,0 +1,93 @@
+package httpgw
+
+import (
+	"time"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+// Option of the gateway
+type Option func(opts *Options)
+
+// Options of the gateway
+type Options struct {
+	// Publisher of the events, events are executed by the mux if it's not defined
+	Publisher asyncp.Publisher
+
+	// Caller awaits results of the published events
+	Caller *asyncp.Caller
+
+	// Mux executes events directly or defines codecs and envelope options of the published events
+	Mux *asyncp.TaskMux
+
+	// Auth checks every request before the processing
+	Auth AuthFnk
+
+	// Events allowed to be sent through the gateway, all events are allowed if empty
+	Events []string
+
+	// Timeout of the result waiting
+	Timeout time.Duration
+
+	// MaxBodySize of the request in bytes
+	MaxBodySize int64
+
+	// ErrorLog of the background event execution
+	ErrorLog func(eventName string, err error)
+}
+
+// WithPublisher sends events to the stream
+func WithPublisher(pub asyncp.Publisher) Option {
+	return func(opts *Options) {
+		opts.Publisher = pub
+	}
+}
+
+// WithCaller awaits results of the published events from the reply stream
+func WithCaller(caller *asyncp.Caller) Option {
+	return func(opts *Options) {
+		opts.Caller = caller
+	}
+}
+
+// WithMux executes events on the mux if the publisher is not defined
+func WithMux(mux *asyncp.TaskMux) Option {
+	return func(opts *Options) {
+		opts.Mux = mux
+	}
+}
+
+// WithAuth checks requests by the auth function
+func WithAuth(auth AuthFnk) Option {
+	return func(opts *Options) {
+		opts.Auth = auth
+	}
+}
+
+// WithEvents allows only listed events
+func WithEvents(names ...string) Option {
+	return func(opts *Options) {
+		opts.Events = append(opts.Events, names...)
+	}
+}
+
+// WithTimeout of the result waiting
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.Timeout = timeout
+	}
+}
+
+// WithMaxBodySize limits the request body size in bytes
+func WithMaxBodySize(size int64) Option {
+	return func(opts *Options) {
+		opts.MaxBodySize = size
+	}
+}
+
+// WithErrorLog of the background event execution
+func WithErrorLog(fn func(eventName string, err error)) Option {
+	return func(opts *Options) {
+		opts.ErrorLog = fn
+	}
+}
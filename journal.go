@@ -0,0 +1,52 @@
+package asyncp
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/demdxx/asyncp/v2/journal"
+)
+
+type (
+	// Journal records events received and emitted by the mux
+	Journal = journal.Journal
+
+	// JournalRecord of the event
+	JournalRecord = journal.Record
+)
+
+// journalEvent writes the event to the journal if it's defined
+func (srv *TaskMux) journalEvent(dir journal.Direction, ev Event) {
+	if srv.journal == nil {
+		return
+	}
+	data, err := srv.encodeStoredEvent(ev)
+	if err != nil {
+		log.Printf("journal encode %s: %s", ev.Name(), err.Error())
+		return
+	}
+	rec := &JournalRecord{
+		Direction: dir,
+		ID:        ev.ID().String(),
+		Name:      ev.Name(),
+		Err:       errorString(ev.Err()),
+		Event:     data,
+	}
+	if err := srv.journal.Write(rec); err != nil {
+		log.Printf("journal write %s: %s", ev.Name(), err.Error())
+	}
+}
+
+// journalMessage writes the message which can't be decoded
+func (srv *TaskMux) journalMessage(msg Message, decodeErr error) {
+	if srv.journal == nil {
+		return
+	}
+	rec := &JournalRecord{Direction: journal.DirectionIn, Err: decodeErr.Error()}
+	if body := msg.Body(); json.Valid(body) {
+		rec.Event = body
+	}
+	if err := srv.journal.Write(rec); err != nil {
+		log.Printf("journal write: %s", err.Error())
+	}
+}
@@ -0,0 +1,359 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	segmentPrefix = "segment-"
+	segmentExt    = ".jsonl"
+	indexExt      = ".idx"
+)
+
+// ErrClosed in case of writing to the closed journal
+var ErrClosed = errors.New(`journal is closed`)
+
+// ErrBroken in case of writing to the journal after the unrecoverable write error
+var ErrBroken = errors.New(`journal is broken`)
+
+type segment struct {
+	start time.Time
+	path  string
+	size  int64
+}
+
+func (s *segment) indexPath() string {
+	return strings.TrimSuffix(s.path, segmentExt) + indexExt
+}
+
+// FileJournal writes records into JSONL segment files of the directory.
+// Every segment has the index file with offsets, times and IDs of the records.
+type FileJournal struct {
+	mx   sync.Mutex
+	dir  string
+	opts Options
+
+	segments []*segment
+	file     *os.File
+	index    *os.File
+	closed   bool
+
+	// broken after the failed write which can't be rolled back
+	broken bool
+}
+
+// Open the journal in the directory, it's created if not exists
+func Open(dir string, options ...Option) (*FileJournal, error) {
+	var opts Options
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	j := &FileJournal{dir: dir, opts: opts}
+	if err := j.loadSegments(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Write record to the current segment
+func (j *FileJournal) Write(rec *Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	if j.closed {
+		return ErrClosed
+	}
+	if j.broken {
+		return ErrBroken
+	}
+	if j.needRotation(rec.Time, len(data)) {
+		if err := j.rotate(rec.Time); err != nil {
+			return err
+		}
+	}
+	current := j.segments[len(j.segments)-1]
+	if _, err := j.file.Write(data); err != nil {
+		// Remove the partial line to keep the segment readable
+		if errTrunc := j.file.Truncate(current.size); errTrunc != nil {
+			j.broken = true
+		}
+		return errors.Wrap(err, "journal write")
+	}
+	line := strconv.FormatInt(current.size, 10) + "\t" +
+		strconv.FormatInt(rec.Time.UnixNano(), 10) + "\t" + rec.ID + "\n"
+	current.size += int64(len(data))
+	if _, err = j.index.WriteString(line); err != nil {
+		// The index can contain the partial line now
+		j.broken = true
+		return errors.Wrap(err, "journal index")
+	}
+	return nil
+}
+
+// Range iterates over records in the time range [from, to).
+// Zero time means no limit.
+func (j *FileJournal) Range(from, to time.Time) iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		segments := j.snapshot()
+		for i, seg := range segments {
+			if !to.IsZero() && !seg.start.Before(to) {
+				return
+			}
+			if !from.IsZero() && i+1 < len(segments) && !segments[i+1].start.After(from) {
+				continue
+			}
+			for rec, err := range readSegment(seg) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if (!from.IsZero() && rec.Time.Before(from)) || (!to.IsZero() && !rec.Time.Before(to)) {
+					continue
+				}
+				if !yield(rec, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Root iterates over records of the chain with the root event ID
+func (j *FileJournal) Root(id string) iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		for _, seg := range j.snapshot() {
+			offsets, err := readIndex(seg, id)
+			stop := false
+			if err == nil && len(offsets) > 0 {
+				stop, err = readRecordsAt(seg, offsets, yield)
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if stop {
+				return
+			}
+		}
+	}
+}
+
+// Close the journal
+func (j *FileJournal) Close() error {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.closed = true
+	return j.closeFiles()
+}
+
+func (j *FileJournal) snapshot() []segment {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	segments := make([]segment, 0, len(j.segments))
+	for _, seg := range j.segments {
+		segments = append(segments, *seg)
+	}
+	return segments
+}
+
+func (j *FileJournal) needRotation(now time.Time, size int) bool {
+	if j.file == nil {
+		return true
+	}
+	current := j.segments[len(j.segments)-1]
+	if j.opts.SegmentSize > 0 && current.size > 0 && current.size+int64(size) > j.opts.SegmentSize {
+		return true
+	}
+	return j.opts.SegmentAge > 0 && now.Sub(current.start) >= j.opts.SegmentAge
+}
+
+func (j *FileJournal) rotate(now time.Time) error {
+	if err := j.closeFiles(); err != nil {
+		return err
+	}
+	if len(j.segments) > 0 && !now.After(j.segments[len(j.segments)-1].start) {
+		// Keep the segments sorted by the start time
+		now = j.segments[len(j.segments)-1].start.Add(time.Nanosecond)
+	}
+	seg := &segment{
+		start: now,
+		path:  filepath.Join(j.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, now.UnixNano(), segmentExt)),
+	}
+	var err error
+	if j.file, err = os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+		return err
+	}
+	if j.index, err = os.OpenFile(seg.indexPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+		return err
+	}
+	j.segments = append(j.segments, seg)
+	return j.applyRetention(now)
+}
+
+// applyRetention removes old segments except the current one
+func (j *FileJournal) applyRetention(now time.Time) error {
+	remove := 0
+	for remove < len(j.segments)-1 {
+		// The segment ends when the next one starts
+		next := j.segments[remove+1]
+		switch {
+		case j.opts.MaxSegments > 0 && len(j.segments)-remove > j.opts.MaxSegments:
+		case j.opts.Retention > 0 && now.Sub(next.start) > j.opts.Retention:
+		default:
+			j.segments = j.segments[remove:]
+			return nil
+		}
+		seg := j.segments[remove]
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Remove(seg.indexPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		remove++
+	}
+	j.segments = j.segments[remove:]
+	return nil
+}
+
+func (j *FileJournal) closeFiles() error {
+	var err error
+	if j.file != nil {
+		err = j.file.Close()
+		j.file = nil
+	}
+	if j.index != nil {
+		if errIdx := j.index.Close(); err == nil {
+			err = errIdx
+		}
+		j.index = nil
+	}
+	return err
+}
+
+// loadSegments of the directory, new records are written to the new segment
+func (j *FileJournal) loadSegments() error {
+	files, err := filepath.Glob(filepath.Join(j.dir, segmentPrefix+"*"+segmentExt))
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), segmentPrefix), segmentExt)
+		nano, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		j.segments = append(j.segments, &segment{start: time.Unix(0, nano), path: path, size: info.Size()})
+	}
+	sort.Slice(j.segments, func(a, b int) bool { return j.segments[a].start.Before(j.segments[b].start) })
+	return nil
+}
+
+func readSegment(seg segment) iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		file, err := os.Open(seg.path)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer file.Close()
+		rd := bufio.NewReader(io.LimitReader(file, seg.size))
+		for {
+			line, err := rd.ReadBytes('\n')
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			var rec Record
+			if err := json.Unmarshal(line, &rec); err != nil {
+				yield(nil, errors.Wrap(err, seg.path))
+				return
+			}
+			if !yield(&rec, nil) {
+				return
+			}
+		}
+	}
+}
+
+func readIndex(seg segment, id string) ([]int64, error) {
+	file, err := os.Open(seg.indexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	var (
+		offsets []int64
+		scanner = bufio.NewScanner(file)
+	)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 || fields[2] != id {
+			continue
+		}
+		offset, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil || offset >= seg.size {
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets, scanner.Err()
+}
+
+// readRecordsAt yields records at the offsets and returns true if the iteration is stopped
+func readRecordsAt(seg segment, offsets []int64, yield func(*Record, error) bool) (bool, error) {
+	file, err := os.Open(seg.path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	for _, offset := range offsets {
+		rd := bufio.NewReader(io.NewSectionReader(file, offset, seg.size-offset))
+		line, err := rd.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return false, errors.Wrap(err, seg.path)
+		}
+		if !yield(&rec, nil) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
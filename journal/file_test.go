@@ -0,0 +1,86 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collect(t *testing.T, seq func(func(*Record, error) bool)) []string {
+	var names []string
+	for rec, err := range seq {
+		if !assert.NoError(t, err) {
+			break
+		}
+		names = append(names, rec.Name)
+	}
+	return names
+}
+
+func TestFileJournal(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		start = time.Now().Add(-time.Hour)
+	)
+	j, err := Open(dir, WithSegmentSize(200))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		id := "root1"
+		if i%2 == 1 {
+			id = "root2"
+		}
+		assert.NoError(t, j.Write(&Record{
+			Time:      start.Add(time.Duration(i) * time.Minute),
+			Direction: DirectionIn,
+			ID:        id,
+			Name:      name,
+			Event:     []byte(`{"name":"` + name + `"}`),
+		}))
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	assert.Greater(t, len(segments), 1, "segments should be rotated")
+
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, collect(t, j.Range(time.Time{}, time.Time{})))
+	assert.Equal(t, []string{"c", "d"}, collect(t, j.Range(start.Add(2*time.Minute), start.Add(4*time.Minute))))
+	assert.Equal(t, []string{"b", "d", "f"}, collect(t, j.Root("root2")))
+	// The break stops the iteration over all segments
+	for rec := range j.Root("root2") {
+		assert.Equal(t, "b", rec.Name)
+		break
+	}
+	assert.NoError(t, j.Close())
+	assert.ErrorIs(t, j.Write(&Record{Name: "g"}), ErrClosed)
+
+	// Reopened journal keeps the old segments and writes the new one
+	j, err = Open(dir, WithMaxSegments(2))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"a", "c", "e"}, collect(t, j.Root("root1")))
+		assert.NoError(t, j.Write(&Record{ID: "root1", Name: "g"}))
+		segments, _ = filepath.Glob(filepath.Join(dir, "*"))
+		assert.Len(t, segments, 4, "two segments with indexes are kept")
+		assert.Equal(t, []string{"g"}, collect(t, j.Range(time.Now().Add(-time.Minute), time.Time{})))
+		assert.NoError(t, j.Close())
+	}
+}
+
+func TestFileJournalRetention(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, WithSegmentAge(time.Minute), WithRetention(time.Hour))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer j.Close()
+	now := time.Now()
+	for i, tm := range []time.Time{now.Add(-3 * time.Hour), now.Add(-2 * time.Hour), now.Add(-30 * time.Minute), now} {
+		assert.NoError(t, j.Write(&Record{Time: tm, Name: string(rune('a' + i))}))
+	}
+	// The segment ends when the next one starts, so only the first one is expired
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 6)
+	assert.Equal(t, []string{"b", "c", "d"}, collect(t, j.Range(time.Time{}, time.Time{})))
+}
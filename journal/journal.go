@@ -0,0 +1,36 @@
+// Package journal keeps the append-only record of the events received and emitted by the mux
+package journal
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Direction of the event relative to the mux
+type Direction string
+
+// Direction list...
+const (
+	DirectionIn  Direction = "in"
+	DirectionOut Direction = "out"
+)
+
+// Record of the journal
+type Record struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"dir"`
+
+	// ID of the root event, it's the same for all events of the chain
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Err  string `json:"error,omitempty"`
+
+	// Event encoded in the envelope format which can be published again
+	Event json.RawMessage `json:"event,omitempty"`
+}
+
+// Journal of the events
+type Journal interface {
+	// Write record to the journal
+	Write(rec *Record) error
+}
@@ -0,0 +1,49 @@
+package journal
+
+import "time"
+
+// Option of the file journal
+type Option func(opts *Options)
+
+// Options of the file journal
+type Options struct {
+	// SegmentSize in bytes after which the new segment is started
+	SegmentSize int64
+
+	// SegmentAge after which the new segment is started
+	SegmentAge time.Duration
+
+	// Retention of the segments, older segments are removed at the rotation
+	Retention time.Duration
+
+	// MaxSegments keeps only the last segments
+	MaxSegments int
+}
+
+// WithSegmentSize rotates segments larger than the size in bytes
+func WithSegmentSize(size int64) Option {
+	return func(opts *Options) {
+		opts.SegmentSize = size
+	}
+}
+
+// WithSegmentAge rotates segments older than the age
+func WithSegmentAge(age time.Duration) Option {
+	return func(opts *Options) {
+		opts.SegmentAge = age
+	}
+}
+
+// WithRetention removes segments older than the retention period
+func WithRetention(retention time.Duration) Option {
+	return func(opts *Options) {
+		opts.Retention = retention
+	}
+}
+
+// WithMaxSegments keeps only the count of the last segments
+func WithMaxSegments(count int) Option {
+	return func(opts *Options) {
+		opts.MaxSegments = count
+	}
+}
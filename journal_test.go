@@ -0,0 +1,78 @@
+package asyncp
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/encryption"
+	"github.com/demdxx/asyncp/v2/journal"
+)
+
+type testJournal struct {
+	mx      sync.Mutex
+	records []*JournalRecord
+}
+
+func (j *testJournal) Write(rec *JournalRecord) error {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.records = append(j.records, rec)
+	return nil
+}
+
+func TestMuxJournal(t *testing.T) {
+	var (
+		jrn = &testJournal{}
+		mux = NewTaskMux(WithJournal(jrn))
+		ev  = WithPayload("test", 1)
+	)
+	mux.Handle("test", func(i int) int { return i + 1 })
+
+	assert.NoError(t, mux.Receive(mustMessageFrom(ev)))
+	assert.Error(t, mux.Receive(message(`{"invalid"`)))
+
+	if assert.Len(t, jrn.records, 3) {
+		in, out, invalid := jrn.records[0], jrn.records[1], jrn.records[2]
+		assert.Equal(t, journal.DirectionIn, in.Direction)
+		assert.Equal(t, "test", in.Name)
+		assert.Equal(t, ev.ID().String(), in.ID)
+		assert.Equal(t, journal.DirectionOut, out.Direction)
+		assert.Equal(t, ev.ID().String(), out.ID, "all events of the chain have the root ID")
+
+		var replay event
+		if assert.NoError(t, replay.Decode(out.Event)) {
+			var v int
+			assert.NoError(t, replay.Payload().Decode(&v))
+			assert.Equal(t, 2, v)
+		}
+		assert.NotEmpty(t, invalid.Err)
+		assert.Empty(t, invalid.Event)
+	}
+}
+
+func TestMuxJournalEncryption(t *testing.T) {
+	var (
+		jrn = &testJournal{}
+		enc = encryption.NewAESGCM(encryption.NewStaticKeyProvider("k1", map[string][]byte{"k1": []byte("0123456789abcdef")}))
+		mux = NewTaskMux(WithJournal(jrn), WithEncryption(enc))
+	)
+	mux.Handle("test", func(s string) string { return s + "!" })
+	assert.NoError(t, mux.Receive(mustMessageFrom(WithPayload("test", "personal data"))))
+
+	if assert.Len(t, jrn.records, 2) {
+		for _, rec := range jrn.records {
+			assert.NotContains(t, string(rec.Event), "personal data")
+			assert.Contains(t, string(rec.Event), `"key_id":"k1"`)
+		}
+		var (
+			res    string
+			replay event
+		)
+		if assert.NoError(t, replay.decode(jrn.records[1].Event, mux)) {
+			assert.NoError(t, replay.Payload().Decode(&res))
+			assert.Equal(t, "personal data!", res)
+		}
+	}
+}
@@ -8,6 +8,9 @@ var (
 
 	// ErrNil in case of empty response
 	ErrNil = errors.New("nil response")
+
+	// ErrValidation in case of payload doesn't match the schema
+	ErrValidation = errors.New("validation error")
 )
 
 func ErrorString(err error) string {
@@ -27,3 +30,7 @@ func StringError(msg string) error {
 func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
+
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
@@ -14,6 +14,14 @@ var ErrNullMessageValue = errors.New(`the value message is nil`)
 // Message this is the internal type of message
 type Message = notificationcenter.Message
 
+// NackMessage is the message which can be returned to the stream for other consumers
+type NackMessage interface {
+	Message
+
+	// Nack returns the message to the stream without processing
+	Nack() error
+}
+
 type message []byte
 
 func messageFrom(value any) (message, error) {
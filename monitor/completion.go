@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventStatus of the root event processing by the task chain
+type EventStatus struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+
+	// Pending count of the events which are not processed yet
+	Pending int64 `json:"pending"`
+
+	// Tasks finished by the event and its descendants
+	Tasks []string `json:"tasks,omitempty"`
+
+	// Errors of the failed tasks
+	Errors map[string]string `json:"errors,omitempty"`
+
+	// Root and Result encoded events of the chain
+	Root   []byte `json:"root,omitempty"`
+	Result []byte `json:"result,omitempty"`
+}
+
+// IsComplete returns true if all events of the chain are processed
+func (st *EventStatus) IsComplete() bool {
+	return st != nil && st.Pending <= 0
+}
+
+// IsFailed returns true if any task of the chain is failed
+func (st *EventStatus) IsFailed() bool {
+	return st != nil && len(st.Errors) > 0
+}
+
+// CompletionStore keeps processing state of the root events
+type CompletionStore interface {
+	// Begin tracking of the root event
+	Begin(id, name string, root []byte) error
+
+	// Spawn registers the count of new events of the chain
+	Spawn(id string, count int) error
+
+	// Result of the chain saves the last encoded response
+	Result(id string, result []byte) error
+
+	// Done registers the finished task and returns the count of pending events
+	Done(id, task string, taskErr error) (int64, error)
+
+	// EventStatus returns the processing state of the root event
+	EventStatus(id string) (*EventStatus, error)
+}
+
+type memoryCompletionItem struct {
+	status    EventStatus
+	updatedAt time.Time
+}
+
+// MemoryCompletionStore keeps the state of the events in the process memory
+type MemoryCompletionStore struct {
+	mx       sync.Mutex
+	lifetime time.Duration
+	items    map[string]*memoryCompletionItem
+	lastGC   time.Time
+}
+
+// NewMemoryCompletionStore returns the store which forgets events
+// after the lifetime since the last update
+func NewMemoryCompletionStore(lifetime time.Duration) *MemoryCompletionStore {
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	return &MemoryCompletionStore{lifetime: lifetime, items: map[string]*memoryCompletionItem{}}
+}
+
+// Begin tracking of the root event
+func (s *MemoryCompletionStore) Begin(id, name string, root []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.gc()
+	s.items[id] = &memoryCompletionItem{
+		status: EventStatus{
+			ID:        id,
+			Name:      name,
+			StartedAt: time.Now(),
+			Pending:   1,
+			Root:      root,
+		},
+		updatedAt: time.Now(),
+	}
+	return nil
+}
+
+// Spawn registers the count of new events of the chain
+func (s *MemoryCompletionStore) Spawn(id string, count int) error {
+	s.update(id, func(st *EventStatus) { st.Pending += int64(count) })
+	return nil
+}
+
+// Result of the chain saves the last encoded response
+func (s *MemoryCompletionStore) Result(id string, result []byte) error {
+	s.update(id, func(st *EventStatus) { st.Result = result })
+	return nil
+}
+
+// Done registers the finished task and returns the count of pending events
+func (s *MemoryCompletionStore) Done(id, task string, taskErr error) (int64, error) {
+	pending := int64(-1)
+	s.update(id, func(st *EventStatus) {
+		st.Pending--
+		pending = st.Pending
+		if taskErr != nil {
+			if st.Errors == nil {
+				st.Errors = map[string]string{}
+			}
+			st.Errors[task] = taskErr.Error()
+		} else if i := sort.SearchStrings(st.Tasks, task); i == len(st.Tasks) || st.Tasks[i] != task {
+			st.Tasks = append(st.Tasks, "")
+			copy(st.Tasks[i+1:], st.Tasks[i:])
+			st.Tasks[i] = task
+		}
+	})
+	return pending, nil
+}
+
+// EventStatus returns the processing state of the root event
+func (s *MemoryCompletionStore) EventStatus(id string) (*EventStatus, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	item := s.items[id]
+	if item == nil {
+		return nil, nil
+	}
+	status := item.status
+	status.Tasks = append([]string(nil), status.Tasks...)
+	if item.status.Errors != nil {
+		status.Errors = make(map[string]string, len(item.status.Errors))
+		for task, msg := range item.status.Errors {
+			status.Errors[task] = msg
+		}
+	}
+	return &status, nil
+}
+
+func (s *MemoryCompletionStore) update(id string, fn func(st *EventStatus)) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if item := s.items[id]; item != nil {
+		fn(&item.status)
+		item.updatedAt = time.Now()
+	}
+}
+
+// gc removes expired events, not often than once per lifetime
+func (s *MemoryCompletionStore) gc() {
+	now := time.Now()
+	if now.Sub(s.lastGC) < s.lifetime {
+		return
+	}
+	s.lastGC = now
+	for id, item := range s.items {
+		if now.Sub(item.updatedAt) > s.lifetime {
+			delete(s.items, id)
+		}
+	}
+}
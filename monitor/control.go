@@ -0,0 +1,58 @@
+package monitor
+
+import "time"
+
+// AllTasks target of the control which is applied to the whole node
+const AllTasks = "*"
+
+// ControlAction of the task processing
+type ControlAction string
+
+// ControlAction list...
+const (
+	// ControlPause holds new events of the task in memory of the node until the resume
+	ControlPause ControlAction = "pause"
+
+	// ControlDrain returns new events of the task to the broker for redelivery,
+	// for the whole node it stops receiving of new events
+	ControlDrain ControlAction = "drain"
+)
+
+// Control state of the task processing by the cluster nodes.
+// The state is active until it's deleted from the store which resumes the processing.
+type Control struct {
+	// App name of the nodes, empty for all applications
+	App string `json:"app,omitempty"`
+
+	// Host IP or hostname of the node, empty for all nodes
+	Host string `json:"host,omitempty"`
+
+	// Task name or AllTasks for the whole node
+	Task string `json:"task"`
+
+	Action    ControlAction `json:"action"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Match returns true if the control is applied to the node
+func (c *Control) Match(app, host, hostname string) bool {
+	return (c.App == "" || c.App == app) &&
+		(c.Host == "" || c.Host == host || c.Host == hostname)
+}
+
+// IsNode returns true if the control is applied to the whole node
+func (c *Control) IsNode() bool {
+	return c.Task == "" || c.Task == AllTasks
+}
+
+// ControlStore keeps control states of the cluster nodes
+type ControlStore interface {
+	// SetControl state of the task processing
+	SetControl(ctrl *Control) error
+
+	// DeleteControl state of the task processing which resumes it
+	DeleteControl(ctrl *Control) error
+
+	// Controls returns all active control states
+	Controls() ([]*Control, error)
+}
@@ -45,6 +45,11 @@ func (acc *Accessor) Incr(key string) (int64, error) {
 	return acc.conn.Incr(key).Result()
 }
 
+// IncrBy value with key
+func (acc *Accessor) IncrBy(key string, value int64) (int64, error) {
+	return acc.conn.IncrBy(key, value).Result()
+}
+
 // Set value for the key
 func (acc *Accessor) Set(key string, value any, expiration ...time.Duration) error {
 	var exp time.Duration
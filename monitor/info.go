@@ -6,16 +6,21 @@ import (
 	"time"
 
 	"github.com/demdxx/asyncp/v2/libs/errors"
+	"github.com/demdxx/asyncp/v2/schema"
 )
 
 // ApplicationInfo with basic description
 type ApplicationInfo struct {
-	Name     string               `json:"name"`
-	Host     string               `json:"host"`
-	Hostname string               `json:"hostname"`
-	InitedAt time.Time            `json:"inited_at"`
-	Tasks    map[string][]string  `json:"tasks"`
-	Servers  map[string]time.Time `json:"servers,omitempty"`
+	Name     string                 `json:"name"`
+	Host     string                 `json:"host"`
+	Hostname string                 `json:"hostname"`
+	InitedAt time.Time              `json:"inited_at"`
+	Tasks    map[string][]string    `json:"tasks"`
+	Schemas  map[string]*TaskSchema `json:"schemas,omitempty"`
+	Servers  map[string]time.Time   `json:"servers,omitempty"`
+
+	// TaskApps contains names of the applications which define the task
+	TaskApps map[string][]string `json:"task_apps,omitempty"`
 }
 
 // Merge application info
@@ -33,21 +38,56 @@ func (app *ApplicationInfo) Merge(info *ApplicationInfo) {
 			app.Tasks[taskName] = append([]string{}, taskTarget...)
 		}
 	}
+	for taskName := range info.Tasks {
+		if info.Name != "" && !strings.HasPrefix(taskName, "@") {
+			app.addTaskApp(taskName, info.Name)
+		}
+	}
+	for taskName, apps := range info.TaskApps {
+		for _, appName := range apps {
+			app.addTaskApp(taskName, appName)
+		}
+	}
+	if info.Schemas != nil {
+		if app.Schemas == nil {
+			app.Schemas = make(map[string]*TaskSchema, len(info.Schemas))
+		}
+		for taskName, taskSchema := range info.Schemas {
+			newSchema := *taskSchema
+			if newSchema.App == "" {
+				newSchema.App = info.Name
+			}
+			app.Schemas[taskName] = &newSchema
+		}
+	}
+}
+
+func (app *ApplicationInfo) addTaskApp(taskName, appName string) {
+	if app.TaskApps == nil {
+		app.TaskApps = map[string][]string{}
+	}
+	if !slices.Contains(app.TaskApps[taskName], appName) {
+		app.TaskApps[taskName] = append(app.TaskApps[taskName], appName)
+		slices.Sort(app.TaskApps[taskName])
+	}
 }
 
 // TaskInfo aggregated in one record
 type TaskInfo struct {
-	ID           string        `json:"id,omitempty"`
-	TotalCount   uint64        `json:"total_count"`
-	ErrorCount   uint64        `json:"error_count"`
-	SuccessCount uint64        `json:"success_count"`
-	SkipCount    uint64        `json:"skip_count"`
-	MinExecTime  time.Duration `json:"min_exec_time"`
-	AvgExecTime  time.Duration `json:"avg_exec_time"`
-	MaxExecTime  time.Duration `json:"max_exec_time"`
-	TaskNames    []string      `json:"task_names,omitempty"` // The list of finished task names
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
+	ID              string        `json:"id,omitempty"`
+	TotalCount      uint64        `json:"total_count"`
+	ErrorCount      uint64        `json:"error_count"`
+	SuccessCount    uint64        `json:"success_count"`
+	SkipCount       uint64        `json:"skip_count"`
+	ValidationCount uint64        `json:"validation_count,omitempty"` // Count of payloads rejected by the schema
+	MinExecTime     time.Duration `json:"min_exec_time"`
+	AvgExecTime     time.Duration `json:"avg_exec_time"`
+	MaxExecTime     time.Duration `json:"max_exec_time"`
+	PayloadBytes    uint64        `json:"payload_bytes,omitempty"`    // Size of the payloads before compression
+	CompressedBytes uint64        `json:"compressed_bytes,omitempty"` // Size of the payloads after compression
+	TaskNames       []string      `json:"task_names,omitempty"`       // The list of finished task names
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
 }
 
 // Inc counters
@@ -56,6 +96,8 @@ func (task *TaskInfo) Inc(err error, execTime time.Duration) {
 	if err != nil {
 		if errors.Is(err, errors.ErrSkipEvent) || strings.Contains(err.Error(), "skip event") {
 			task.SkipCount++
+		} else if IsValidationError(err) {
+			task.ValidationCount++
 		} else {
 			task.ErrorCount++
 		}
@@ -85,6 +127,9 @@ func (task *TaskInfo) Add(info *TaskInfo) {
 	task.ErrorCount += info.ErrorCount
 	task.SuccessCount += info.SuccessCount
 	task.SkipCount += info.SkipCount
+	task.ValidationCount += info.ValidationCount
+	task.PayloadBytes += info.PayloadBytes
+	task.CompressedBytes += info.CompressedBytes
 	if task.MinExecTime == 0 || task.MinExecTime > info.MinExecTime {
 		task.MinExecTime = info.MinExecTime
 	}
@@ -102,6 +147,26 @@ func (task *TaskInfo) Add(info *TaskInfo) {
 	task.touch()
 }
 
+// IsValidationError checks if the payload was rejected by the schema
+func IsValidationError(err error) bool {
+	var verr *schema.ValidationError
+	return errors.As(err, &verr)
+}
+
+// AddPayloadSize of the encoded payload
+func (task *TaskInfo) AddPayloadSize(rawSize, encodedSize int) {
+	task.PayloadBytes += uint64(rawSize)
+	task.CompressedBytes += uint64(encodedSize)
+}
+
+// CompressionRatio of the payloads as compressed size to the raw size
+func (task *TaskInfo) CompressionRatio() float64 {
+	if task.PayloadBytes == 0 {
+		return 1
+	}
+	return float64(task.CompressedBytes) / float64(task.PayloadBytes)
+}
+
 // AddTaskName to the list
 func (task *TaskInfo) AddTaskName(name string) {
 	if slices.Contains(task.TaskNames, name) {
@@ -1,6 +1,12 @@
 package kvstorage
 
-import "time"
+import (
+	"time"
+
+	"github.com/demdxx/gocast/v2"
+
+	"github.com/demdxx/asyncp/v2/libs/errors"
+)
 
 // KeyValueBasic provides basic data accessors
 type KeyValueBasic interface {
@@ -26,6 +32,12 @@ type KeyValueBasic interface {
 	Del(key ...string) error
 }
 
+// KeyValueIncrementer is the optional accessor extension to change the value by the delta
+type KeyValueIncrementer interface {
+	// IncrBy value with key
+	IncrBy(key string, value int64) (int64, error)
+}
+
 // KeyValueTxAccessor defines accessor which will apply changes only after committing
 type KeyValueTxAccessor interface {
 	KeyValueBasic
@@ -41,3 +53,20 @@ type KeyValueAccessor interface {
 	// Begin new transaction
 	Begin() (KeyValueTxAccessor, error)
 }
+
+// incrBy changes the value by the delta. Accessors without KeyValueIncrementer
+// are updated by the read and write which is not atomic, so concurrent changes can be lost.
+func incrBy(acc KeyValueBasic, key string, value int64, expiration ...time.Duration) (int64, error) {
+	if inc, ok := acc.(KeyValueIncrementer); ok {
+		return inc.IncrBy(key, value)
+	}
+	if value == 1 {
+		return acc.Incr(key)
+	}
+	cur, err := acc.Get(key)
+	if err != nil && !errors.Is(err, ErrNil) {
+		return 0, err
+	}
+	res := gocast.Number[int64](cur) + value
+	return res, acc.Set(key, res, expiration...)
+}
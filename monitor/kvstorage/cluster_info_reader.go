@@ -146,3 +146,9 @@ func (s *ClusterInfoReader) ResetCache() {
 	defer s.mx.Unlock()
 	s.storageList = s.storageList[:0]
 }
+
+// EventStatus returns the processing state of the root event
+// tracked by the completion store
+func (s *ClusterInfoReader) EventStatus(id string) (*monitor.EventStatus, error) {
+	return NewCompletionStore(s.kvclient, 0).EventStatus(id)
+}
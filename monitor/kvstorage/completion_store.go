@@ -0,0 +1,130 @@
+package kvstorage
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/demdxx/gocast/v2"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+const completionKeyPrefix = "asyncp:event_"
+
+type completionRoot struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+	Data      []byte    `json:"data,omitempty"`
+}
+
+// CompletionStore keeps processing state of the root events
+// in the key-value storage shared by the cluster
+type CompletionStore struct {
+	client   KeyValueAccessor
+	lifetime time.Duration
+}
+
+// NewCompletionStore returns the store with the lifetime of the event state.
+// Pending events are counted atomically only by clients with KeyValueIncrementer.
+func NewCompletionStore(client KeyValueAccessor, lifetime time.Duration) *CompletionStore {
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	return &CompletionStore{client: client, lifetime: lifetime}
+}
+
+// Begin tracking of the root event
+func (s *CompletionStore) Begin(id, name string, root []byte) error {
+	data, err := json.Marshal(&completionRoot{Name: name, StartedAt: time.Now(), Data: root})
+	if err != nil {
+		return err
+	}
+	tx, err := s.client.Begin()
+	if err != nil {
+		return err
+	}
+	_ = tx.Set(s.key(id, "root"), string(data), s.lifetime)
+	// Increments keep the expiration of the key
+	_ = tx.Set(s.key(id, "pending"), 1, s.lifetime)
+	return tx.Commit()
+}
+
+// Spawn registers the count of new events of the chain
+func (s *CompletionStore) Spawn(id string, count int) error {
+	_, err := incrBy(s.client, s.key(id, "pending"), int64(count), s.lifetime)
+	return err
+}
+
+// Result of the chain saves the last encoded response
+func (s *CompletionStore) Result(id string, result []byte) error {
+	return s.client.Set(s.key(id, "result"), string(result), s.lifetime)
+}
+
+// Done registers the finished task and returns the count of pending events
+func (s *CompletionStore) Done(id, task string, taskErr error) (int64, error) {
+	// The task is saved before the counter, so it's visible at the chain completion
+	if err := s.client.Set(s.key(id, "task_"+task), errorMessage(taskErr), s.lifetime); err != nil {
+		return 0, err
+	}
+	return incrBy(s.client, s.key(id, "pending"), -1, s.lifetime)
+}
+
+// EventStatus returns the processing state of the root event
+func (s *CompletionStore) EventStatus(id string) (*monitor.EventStatus, error) {
+	vals, err := s.client.MGet(s.key(id, "root"), s.key(id, "pending"), s.key(id, "result"))
+	if err != nil {
+		return nil, err
+	}
+	if vals[0] == nil {
+		return nil, nil
+	}
+	var root completionRoot
+	if err := json.Unmarshal([]byte(gocast.Str(vals[0])), &root); err != nil {
+		return nil, err
+	}
+	status := &monitor.EventStatus{
+		ID:        id,
+		Name:      root.Name,
+		StartedAt: root.StartedAt,
+		Pending:   gocast.Number[int64](vals[1]),
+		Root:      root.Data,
+	}
+	if vals[2] != nil {
+		status.Result = []byte(gocast.Str(vals[2]))
+	}
+	taskPrefix := s.key(id, "task_")
+	keys, err := s.client.Keys(taskPrefix + "*")
+	if err != nil || len(keys) == 0 {
+		return status, err
+	}
+	messages, err := s.client.MGet(keys...)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		task := strings.TrimPrefix(key, taskPrefix)
+		if msg := gocast.Str(messages[i]); msg != "" {
+			if status.Errors == nil {
+				status.Errors = map[string]string{}
+			}
+			status.Errors[task] = msg
+		} else {
+			status.Tasks = append(status.Tasks, task)
+		}
+	}
+	sort.Strings(status.Tasks)
+	return status, nil
+}
+
+func (s *CompletionStore) key(id, name string) string {
+	return completionKeyPrefix + id + "_" + name
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,90 @@
+package kvstorage
+
+import (
+	"errors"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testKV map[string]any
+
+func (kv testKV) Keys(pattern string) ([]string, error) {
+	var keys []string
+	for key := range kv {
+		if ok, _ := path.Match(pattern, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+func (kv testKV) Get(key string) (any, error) { return kv[key], nil }
+func (kv testKV) MGet(keys ...string) ([]any, error) {
+	vals := make([]any, 0, len(keys))
+	for _, key := range keys {
+		vals = append(vals, kv[key])
+	}
+	return vals, nil
+}
+func (kv testKV) Incr(key string) (int64, error) { return kv.IncrBy(key, 1) }
+func (kv testKV) IncrBy(key string, value int64) (int64, error) {
+	kv[key] = gocast.Number[int64](kv[key]) + value
+	return kv[key].(int64), nil
+}
+func (kv testKV) Set(key string, value any, _ ...time.Duration) error { kv[key] = value; return nil }
+func (kv testKV) MSet(vals ...any) error {
+	for i := 0; i < len(vals)-1; i += 2 {
+		kv[gocast.Str(vals[i])] = vals[i+1]
+	}
+	return nil
+}
+func (kv testKV) Del(keys ...string) error {
+	for _, key := range keys {
+		delete(kv, key)
+	}
+	return nil
+}
+func (kv testKV) Begin() (KeyValueTxAccessor, error) { return kv, nil }
+func (kv testKV) Commit() error                      { return nil }
+
+func TestCompletionStore(t *testing.T) {
+	for name, newClient := range map[string]func(kv testKV) KeyValueAccessor{
+		"incrementer": func(kv testKV) KeyValueAccessor { return kv },
+		"basic":       func(kv testKV) KeyValueAccessor { return struct{ KeyValueAccessor }{kv} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				kv    = testKV{}
+				store = NewCompletionStore(newClient(kv), time.Minute)
+			)
+			assert.NoError(t, store.Begin("id", "rss", []byte(`{}`)))
+			assert.NoError(t, store.Spawn("id", 2))
+			for _, task := range []string{"rss", "rss.1"} {
+				pending, err := store.Done("id", task, nil)
+				assert.NoError(t, err)
+				assert.NotZero(t, pending)
+			}
+			assert.NoError(t, store.Result("id", []byte(`result`)))
+			pending, err := store.Done("id", "video", errors.New("failed"))
+			assert.NoError(t, err)
+			assert.Zero(t, pending)
+
+			status, err := NewClusterInfoReader(kv).EventStatus("id")
+			if assert.NoError(t, err) && assert.NotNil(t, status) {
+				assert.Equal(t, "rss", status.Name)
+				assert.True(t, status.IsComplete())
+				assert.True(t, status.IsFailed())
+				assert.Equal(t, []string{"rss", "rss.1"}, status.Tasks)
+				assert.Equal(t, map[string]string{"video": "failed"}, status.Errors)
+				assert.Equal(t, []byte(`result`), status.Result)
+			}
+
+			status, err = store.EventStatus("unknown")
+			assert.NoError(t, err)
+			assert.Nil(t, status)
+		})
+	}
+}
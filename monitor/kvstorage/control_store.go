@@ -0,0 +1,70 @@
+package kvstorage
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/demdxx/gocast/v2"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+const controlKeyPrefix = "asyncp:control_"
+
+// ControlStore keeps control states of the cluster nodes in the key-value storage
+type ControlStore struct {
+	client KeyValueAccessor
+}
+
+// NewControlStore returns the store of the control states
+func NewControlStore(client KeyValueAccessor) *ControlStore {
+	return &ControlStore{client: client}
+}
+
+// SetControl state of the task processing
+func (s *ControlStore) SetControl(ctrl *monitor.Control) error {
+	data, err := json.Marshal(ctrl)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(s.key(ctrl), string(data))
+}
+
+// DeleteControl state of the task processing
+func (s *ControlStore) DeleteControl(ctrl *monitor.Control) error {
+	return s.client.Del(s.key(ctrl))
+}
+
+// Controls returns all active control states ordered by the key
+func (s *ControlStore) Controls() ([]*monitor.Control, error) {
+	keys, err := s.client.Keys(controlKeyPrefix + "*")
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	sort.Strings(keys)
+	vals, err := s.client.MGet(keys...)
+	if err != nil {
+		return nil, err
+	}
+	controls := make([]*monitor.Control, 0, len(vals))
+	for _, val := range vals {
+		if val == nil {
+			continue
+		}
+		var ctrl monitor.Control
+		if err := json.Unmarshal([]byte(gocast.Str(val)), &ctrl); err != nil {
+			return nil, err
+		}
+		controls = append(controls, &ctrl)
+	}
+	return controls, nil
+}
+
+// key of the control is unique for the app, host and task
+func (s *ControlStore) key(ctrl *monitor.Control) string {
+	task := ctrl.Task
+	if task == "" {
+		task = monitor.AllTasks
+	}
+	return controlKeyPrefix + gocast.Or(ctrl.App, "*") + "_" + gocast.Or(ctrl.Host, "*") + "_" + task
+}
@@ -0,0 +1,35 @@
+package kvstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+func TestControlStore(t *testing.T) {
+	var (
+		kv    = testKV{}
+		store = NewControlStore(kv)
+		pause = &monitor.Control{App: "video", Task: "video", Action: monitor.ControlPause}
+		drain = &monitor.Control{Host: "10.0.0.1", Action: monitor.ControlDrain}
+	)
+	assert.NoError(t, store.SetControl(pause))
+	assert.NoError(t, store.SetControl(drain))
+	assert.Contains(t, kv, "asyncp:control_video_*_video")
+	assert.Contains(t, kv, "asyncp:control_*_10.0.0.1_*")
+
+	controls, err := store.Controls()
+	if assert.NoError(t, err) && assert.Len(t, controls, 2) {
+		assert.Equal(t, drain.Host, controls[0].Host)
+		assert.True(t, controls[0].IsNode())
+		assert.True(t, controls[0].Match("rss", "10.0.0.1", "node1"))
+		assert.False(t, controls[1].Match("rss", "10.0.0.1", "node1"))
+	}
+
+	assert.NoError(t, store.DeleteControl(pause))
+	controls, err = store.Controls()
+	assert.NoError(t, err)
+	assert.Len(t, controls, 1)
+}
@@ -24,6 +24,8 @@ type Storage struct {
 
 	client KeyValueAccessor
 
+	// infoMx protects appInfo separately, keys are built under the main lock
+	infoMx   sync.RWMutex
 	appInfo  *monitor.ApplicationInfo
 	taskInfo map[string]*monitor.TaskInfo
 
@@ -59,20 +61,28 @@ func newWithApplication(client KeyValueAccessor, name, host string) (*Storage, e
 
 // ApplicationInfo returns application information
 func (s *Storage) ApplicationInfo() *monitor.ApplicationInfo {
+	s.infoMx.RLock()
+	defer s.infoMx.RUnlock()
 	return s.appInfo
 }
 
 func (s *Storage) loadApplicationInfo(name, host string) (*monitor.ApplicationInfo, error) {
 	mainKey := fmt.Sprintf("%s:app_%s", name, host)
-	if err := s.getJSON(mainKey, &s.appInfo); err != nil {
+	appInfo := &monitor.ApplicationInfo{}
+	if err := s.getJSON(mainKey, &appInfo); err != nil {
 		return nil, err
 	}
-	return s.appInfo, nil
+	s.infoMx.Lock()
+	s.appInfo = appInfo
+	s.infoMx.Unlock()
+	return appInfo, nil
 }
 
 // RegisterApplication info in the storage
 func (s *Storage) RegisterApplication(appInfo *monitor.ApplicationInfo) error {
+	s.infoMx.Lock()
 	s.appInfo = appInfo
+	s.infoMx.Unlock()
 	return s.setJSON(s.mainKey(), appInfo, 0)
 }
 
@@ -116,6 +126,9 @@ func (s *Storage) TaskInfo(name string) (*monitor.TaskInfo, error) {
 			s.metricKey(name+"_min"),
 			s.metricKey(name+"_avg"),
 			s.metricKey(name+"_max"),
+			s.metricKey(name+"_payload_bytes"),
+			s.metricKey(name+"_compressed_bytes"),
+			s.metricKey(name+"_validation"),
 		)
 		if err != nil {
 			return nil, err
@@ -124,12 +137,16 @@ func (s *Storage) TaskInfo(name string) (*monitor.TaskInfo, error) {
 			TotalCount:   gocast.Number[uint64](vals[0]),
 			ErrorCount:   gocast.Number[uint64](vals[1]),
 			SkipCount:    gocast.Number[uint64](vals[2]),
-			SuccessCount: gocast.Number[uint64](vals[0]) - gocast.Number[uint64](vals[1]) - gocast.Number[uint64](vals[2]),
+			SuccessCount: successCount(gocast.Number[uint64](vals[0]), gocast.Number[uint64](vals[1]), gocast.Number[uint64](vals[2]), gocast.Number[uint64](vals[8])),
 			MinExecTime:  time.Duration(gocast.Number[int64](vals[3])),
 			AvgExecTime:  time.Duration(gocast.Number[int64](vals[4])),
 			MaxExecTime:  time.Duration(gocast.Number[int64](vals[5])),
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
+
+			PayloadBytes:    gocast.Number[uint64](vals[6]),
+			CompressedBytes: gocast.Number[uint64](vals[7]),
+			ValidationCount: gocast.Number[uint64](vals[8]),
 		}
 		s.taskInfo[name] = taskInfo
 	}
@@ -144,7 +161,7 @@ func (s *Storage) TaskInfoByID(id string) (*monitor.TaskInfo, error) {
 		return nil, err
 	}
 	taskInfo.ID = id
-	taskInfo.SuccessCount = taskInfo.TotalCount - taskInfo.ErrorCount - taskInfo.SkipCount
+	taskInfo.SuccessCount = successCount(taskInfo.TotalCount, taskInfo.ErrorCount, taskInfo.SkipCount, taskInfo.ValidationCount)
 	return taskInfo, nil
 }
 
@@ -180,6 +197,8 @@ func (s *Storage) ExecuteTask(event monitor.EventType, execTime time.Duration) e
 	if event.Err() != nil {
 		if errors.Is(event.Err(), errors.ErrSkipEvent) {
 			_, _ = tx.Incr(s.metricKey(eventName + "_skip"))
+		} else if monitor.IsValidationError(event.Err()) {
+			_, _ = tx.Incr(s.metricKey(eventName + "_validation"))
 		} else {
 			_, _ = tx.Incr(s.metricKey(eventName + "_error"))
 		}
@@ -192,6 +211,27 @@ func (s *Storage) ExecuteTask(event monitor.EventType, execTime time.Duration) e
 	return tx.Commit()
 }
 
+// EncodePayload commits sizes of the encoded event payload
+func (s *Storage) EncodePayload(event monitor.EventType, rawSize, encodedSize int) error {
+	taskInfo, err := s.TaskInfo(event.Name())
+	if err != nil {
+		return err
+	}
+	s.mx.Lock()
+	taskInfo.AddPayloadSize(rawSize, encodedSize)
+	s.mx.Unlock()
+	tx, err := s.client.Begin()
+	if err != nil {
+		return err
+	}
+	// Payload sizes are shared only by the accessors with the increment by the value
+	if inc, ok := tx.(KeyValueIncrementer); ok {
+		_, _ = inc.IncrBy(s.metricKey(event.Name()+"_payload_bytes"), int64(rawSize))
+		_, _ = inc.IncrBy(s.metricKey(event.Name()+"_compressed_bytes"), int64(encodedSize))
+	}
+	return tx.Commit()
+}
+
 // FailoverTaskInfo returns information about the failover task
 func (s *Storage) FailoverTaskInfo(name string) (*monitor.TaskInfo, error) {
 	return s.TaskInfo(failoverTaskName)
@@ -235,9 +275,24 @@ func (s *Storage) getJSON(key string, target any, tx ...KeyValueBasic) error {
 }
 
 func (s *Storage) mainKey() string {
+	s.infoMx.RLock()
+	defer s.infoMx.RUnlock()
 	return fmt.Sprintf("%s:app_%s", s.appInfo.Name, s.appInfo.Host)
 }
 
 func (s *Storage) metricKey(key string) string {
+	s.infoMx.RLock()
+	defer s.infoMx.RUnlock()
 	return fmt.Sprintf("%s:metric_%s_$_%s", s.appInfo.Name, s.appInfo.Host, key)
 }
+
+// successCount of the task; counters are not updated atomically so the failures can exceed the total
+func successCount(total uint64, failed ...uint64) uint64 {
+	for _, count := range failed {
+		if count >= total {
+			return 0
+		}
+		total -= count
+	}
+	return total
+}
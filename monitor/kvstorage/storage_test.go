@@ -0,0 +1,32 @@
+package kvstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessCount(t *testing.T) {
+	assert.Equal(t, uint64(4), successCount(10, 3, 2, 1))
+	assert.Equal(t, uint64(0), successCount(10, 7, 4), "failures can exceed the total")
+	assert.Equal(t, uint64(0), successCount(0, 0))
+}
+
+func TestIncrBy(t *testing.T) {
+	var (
+		kv    = testKV{}
+		basic = struct{ KeyValueBasic }{kv}
+	)
+	val, err := incrBy(basic, "key", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), val)
+	val, err = incrBy(basic, "key", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), val)
+	val, err = incrBy(basic, "new", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), val)
+	val, err = incrBy(kv, "key", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), val)
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/demdxx/asyncp/v2/schema"
+)
+
+// TaskSchema describes the contract of the task payloads
+type TaskSchema struct {
+	App    string          `json:"app,omitempty"`
+	Input  json.RawMessage `json:"input,omitempty"`
+	Output json.RawMessage `json:"output,omitempty"`
+}
+
+// SchemaConflict between the output of the producer task and the input of the consumer task
+type SchemaConflict struct {
+	Producer    string   `json:"producer"`
+	ProducerApp string   `json:"producer_app,omitempty"`
+	Consumer    string   `json:"consumer"`
+	ConsumerApp string   `json:"consumer_app,omitempty"`
+	Problems    []string `json:"problems"`
+}
+
+// String representation of the conflict
+func (c *SchemaConflict) String() string {
+	return c.ProducerApp + ":" + c.Producer + " > " + c.ConsumerApp + ":" + c.Consumer +
+		": " + strings.Join(c.Problems, "; ")
+}
+
+// SchemaConflicts returns the list of incompatible producer and consumer pairs
+// linked in the application task graph
+func SchemaConflicts(appInfo *ApplicationInfo) []SchemaConflict {
+	if appInfo == nil || len(appInfo.Schemas) == 0 {
+		return nil
+	}
+	var conflicts []SchemaConflict
+	for producer, prodSchema := range appInfo.Schemas {
+		if len(prodSchema.Output) == 0 {
+			continue
+		}
+		consumers := append(append([]string{}, appInfo.Tasks[producer]...), appInfo.Tasks["@"+producer]...)
+		for _, consumer := range consumers {
+			consSchema := appInfo.Schemas[consumer]
+			if consSchema == nil || len(consSchema.Input) == 0 {
+				continue
+			}
+			problems, err := schema.Compare(prodSchema.Output, consSchema.Input)
+			if err != nil {
+				problems = []string{err.Error()}
+			}
+			if len(problems) > 0 {
+				conflicts = append(conflicts, SchemaConflict{
+					Producer:    producer,
+					ProducerApp: prodSchema.App,
+					Consumer:    consumer,
+					ConsumerApp: consSchema.App,
+					Problems:    problems,
+				})
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Producer == conflicts[j].Producer {
+			return conflicts[i].Consumer < conflicts[j].Consumer
+		}
+		return conflicts[i].Producer < conflicts[j].Producer
+	})
+	return conflicts
+}
@@ -13,6 +13,11 @@ type MetricUpdater interface {
 	ExecuteFailoverTask(event EventType, execTime time.Duration) error
 }
 
+// PayloadMetricUpdater accumulates sizes of the encoded payloads
+type PayloadMetricUpdater interface {
+	EncodePayload(event EventType, rawSize, encodedSize int) error
+}
+
 // MetricReader of information
 type MetricReader interface {
 	ApplicationInfo() *ApplicationInfo
@@ -33,4 +38,5 @@ type ClusterInfoReader interface {
 	TaskInfo(name string) (*TaskInfo, error)
 	TaskInfoByID(id string) (*TaskInfo, error)
 	ListOfNodes() (map[string][]string, int, error)
+	EventStatus(id string) (*EventStatus, error)
 }
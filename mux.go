@@ -4,17 +4,28 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/geniusrabbit/notificationcenter/v2"
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
+
+	"github.com/demdxx/asyncp/v2/graph"
+	"github.com/demdxx/asyncp/v2/journal"
+	"github.com/demdxx/asyncp/v2/monitor"
 )
 
 // Error list...
 var (
-	ErrChanelTaken = errors.New(`chanel has been taken`)
+	ErrChanelTaken  = errors.New(`chanel has been taken`)
+	ErrTaskNotFound = errors.New(`task not found`)
+	ErrTaskLinked   = errors.New(`task has linked tasks`)
 )
 
 // Stream writing interface
@@ -24,15 +35,15 @@ type Stream = notificationcenter.Publisher
 type TaskMux struct {
 	cluster ClusterExt
 
-	// Chanel name + task with responser
-	tasks map[string]Promise
+	// registry of the tasks is replaced on every change,
+	// so events are processed without locks
+	registry atomic.Pointer[taskRegistry]
 
-	// Maps final task of the chanel with tasks from other chanels or clusters.
-	// All linked external events starts from `@`; @globalEvent -> targetEvent
-	hiddenTaskMapping map[string][]string
+	// registryMx serialises changes of the registry
+	registryMx sync.Mutex
 
-	// Default task if not found
-	failoverTask Promise
+	// inited after FinishInit, changes of the tasks are announced to the cluster
+	inited atomic.Bool
 
 	// mainExecContext as default for any execution request
 	mainExecContext context.Context
@@ -43,14 +54,50 @@ type TaskMux struct {
 	// errorHandler process error responses
 	errorHandler ErrorHandlerFnk
 
+	// validationErrorHandler process payloads rejected by the schema
+	validationErrorHandler ValidationErrorHandlerFnk
+
 	// contextWrapper for execution context preparation
 	contextWrapper ContextWrapperFnk
 
 	// Allocate new specific writer for every event separately
 	responseFactory ResponseWriterFactory
 
+	// proxyWriters pool of the writers if responseFactory is not defined
+	proxyWriters *proxyResponseFactory
+
 	// EventAllocator provides interface of event object management
 	eventAllocator EventAllocator
+
+	// defaultCodec of the response payloads
+	defaultCodec Codec
+
+	// envelopeOpts of the event encoding
+	envelopeOpts *envelopeOptions
+
+	// upcasters of the payload versions by event name
+	upcasters map[string]*eventUpcasters
+
+	// replyPublisher of the chain results for the requests
+	replyPublisher Publisher
+
+	// futures of the submitted events by correlation ID
+	futures sync.Map
+
+	// completion tracking of the root events
+	completion *completionTracker
+
+	// journal of the received and emitted events
+	journal Journal
+
+	// control of the paused tasks and draining
+	control taskControl
+
+	// controlWatcher applies commands of the cluster control store
+	controlWatcher *controlWatcher
+
+	// eventPooling reuses events after the processing
+	eventPooling bool
 }
 
 // NewTaskMux server object
@@ -60,72 +107,137 @@ func NewTaskMux(options ...Option) *TaskMux {
 		opt(&opts)
 	}
 	mux := &TaskMux{
-		tasks:             map[string]Promise{},
-		hiddenTaskMapping: map[string][]string{},
-		panicHandler:      opts.PanicHandler,
-		errorHandler:      opts.ErrorHandler,
-		mainExecContext:   opts.MainExecContext,
-		contextWrapper:    opts.ContextWrapper,
-		responseFactory:   opts.ResponseFactory,
-		cluster:           opts.Cluster,
-		eventAllocator:    opts._eventAllocator(),
+		panicHandler:           opts.PanicHandler,
+		errorHandler:           opts.ErrorHandler,
+		validationErrorHandler: opts.ValidationErrorHandler,
+		mainExecContext:        opts.MainExecContext,
+		contextWrapper:         opts.ContextWrapper,
+		responseFactory:        opts.ResponseFactory,
+		cluster:                opts.Cluster,
+		eventAllocator:         opts._eventAllocator(),
+		defaultCodec:           opts.DefaultCodec,
+		replyPublisher:         opts.ReplyPublisher,
+		completion:             newCompletionTracker(&opts),
+		journal:                opts.Journal,
+		controlWatcher:         newControlWatcher(&opts),
+		control:                taskControl{bufferSize: opts.PauseBufferSize},
+		eventPooling:           opts.EventPooling,
+	}
+	mux.registry.Store(newTaskRegistry())
+	if opts.Compression != nil || opts.Encryption != nil || opts.BlobStore != nil || opts.CloudEvents != nil || opts.EnvelopeVersion != 0 {
+		mux.envelopeOpts = &envelopeOptions{
+			envelopeVersion: opts.EnvelopeVersion,
+			compressor:      opts.Compression,
+			compressMinSize: opts.CompressMinSize,
+			encryptor:       opts.Encryption,
+			blobStore:       opts.BlobStore,
+			blobThreshold:   opts.BlobThreshold,
+			blobTTL:         opts.BlobTTL,
+			cloudEvents:     opts.CloudEvents,
+		}
+	}
+	if mux.responseFactory == nil {
+		mux.proxyWriters = NewProxyResponseFactory().(*proxyResponseFactory)
+		mux.proxyWriters.SetMux(mux)
 	}
 	if muxSet, ok := mux.responseFactory.(interface{ SetMux(mux *TaskMux) }); ok {
 		muxSet.SetMux(mux)
 	}
+	if muxSet, ok := mux.eventAllocator.(interface{ SetMux(mux *TaskMux) }); ok {
+		muxSet.SetMux(mux)
+	}
 	return mux
 }
 
 // Handle register new task for specific chanel
 // Task after other task can be defined by "parentTaskName>currentTaskName"
-func (srv *TaskMux) Handle(taskName string, handler any) Promise {
-	return srv.handleExt(taskName, handler, false)
+func (srv *TaskMux) Handle(taskName string, handler any, options ...TaskOption) Promise {
+	return srv.handleExt(taskName, handler, false, options...)
 }
 
-func (srv *TaskMux) handleExt(name string, handler any, anonymous bool) Promise {
+func (srv *TaskMux) handleExt(name string, handler any, anonymous bool, options ...TaskOption) (prom Promise) {
+	_ = srv.updateTasks(func(reg *taskRegistry) error {
+		prom = srv.registerTask(reg, name, handler, anonymous, options...)
+		return nil
+	})
+	return prom
+}
+
+func (srv *TaskMux) registerTask(reg *taskRegistry, name string, handler any, anonymous bool, options ...TaskOption) Promise {
 	var (
 		parentPromis             Promise
 		parentTaskName, taskName = prepareTaskName(name)
 	)
-	if srv.tasks == nil {
-		srv.tasks = map[string]Promise{}
-	}
-	if _, ok := srv.tasks[taskName]; ok {
+	if _, ok := reg.tasks[taskName]; ok {
 		panic(errors.Wrap(ErrChanelTaken, taskName))
 	}
 	if parentTaskName != "" {
 		// If there is no parent promis in the scope of local tasks
 		// then the parent is external task
-		if parentPromis = srv.tasks[parentTaskName]; parentPromis != nil {
-			parentPromis = parentPromis.LastPromise()
+		if parent, _ := reg.tasks[parentTaskName].(*promise); parent != nil {
+			// Published promises are not changed, the copy with the new target is registered
+			parentPromis = reg.linkTarget(reg.lastPromise(parent).(*promise), taskName)
 		}
 	}
 
-	taskItemValue := newPoromise(srv, parentPromis, taskName, TaskFrom(handler), anonymous)
-	srv.tasks[taskName] = taskItemValue
+	opts := newTaskOptions(options...)
+	taskItemValue := newPoromise(srv, parentPromis, taskName,
+		wrapRetryTask(TaskFrom(handler, options...), &opts), anonymous)
+	taskItemValue.options = opts
+	if err := taskItemValue.options.prepare(handler); err != nil {
+		panic(errors.Wrap(err, taskName))
+	}
+	reg.tasks[taskName] = taskItemValue
 
 	if parentTaskName != "" && parentPromis == nil {
 		// Links global event name and the target external one
 		taskItemValue.parent = newPromisVirtual(parentTaskName, taskName)
 		parentTaskName = "@" + parentTaskName
-		srv.hiddenTaskMapping[parentTaskName] = append(srv.hiddenTaskMapping[parentTaskName], taskName)
-	}
-
-	// If parent task is not virtual then add event to the parent task
-	if parentPromis != nil && !parentPromis.IsVirtual() {
-		parentPromis.ThenEvent(taskName)
+		reg.hiddenTaskMapping[parentTaskName] = append(
+			slices.Clip(reg.hiddenTaskMapping[parentTaskName]), taskName)
 	}
 	return taskItemValue
 }
 
 // Failover handler if was reseaved event with unsappoted event
 func (srv *TaskMux) Failover(task any) error {
-	srv.failoverTask = &promise{task: TaskFrom(task)}
-	return nil
+	failoverTask := &promise{task: TaskFrom(task)}
+	return srv.updateTasks(func(reg *taskRegistry) error {
+		reg.failoverTask = failoverTask
+		return nil
+	})
+}
+
+// Unhandle removes the task with anonymous steps of its chain and closes them.
+// It's safe to call while events are processed, the events which are
+// executed or queued at the moment are finished by the removed task.
+// The task with linked named tasks like `task>next` can't be removed before them.
+func (srv *TaskMux) Unhandle(taskName string) error {
+	var removed []Promise
+	err := srv.updateTasks(func(reg *taskRegistry) (err error) {
+		prom := reg.tasks[taskName]
+		if prom == nil {
+			return errors.Wrap(ErrTaskNotFound, taskName)
+		}
+		removed, err = reg.removeTask(prom)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, prom := range removed {
+		if closer, ok := prom.(io.Closer); ok {
+			err = multierr.Append(err, closer.Close())
+		}
+	}
+	return err
 }
 
 // Receive definds the processing function
 func (srv *TaskMux) Receive(msg Message) error {
+	if srv.control.draining.Load() {
+		return ErrDraining
+	}
 	event, err := srv.eventAllocator.Decode(msg)
 	if event != nil {
 		defer func() {
@@ -141,9 +253,18 @@ func (srv *TaskMux) Receive(msg Message) error {
 		_ = srv.cluster.ReceiveEvent(event, err)
 	}
 	if err != nil {
+		srv.journalMessage(msg, err)
 		return err
 	}
-	if err = srv.ExecuteEvent(event); err != nil {
+	if srv.isRequeued(event.Name()) {
+		return errors.Wrap(ErrTaskPaused, event.Name())
+	}
+	srv.journalEvent(journal.DirectionIn, event)
+	if err = srv.executeEvent(event, msg); err != nil {
+		if errors.Is(err, errEventHeld) {
+			// The message is acked after the resume of the task
+			return nil
+		}
 		return err
 	}
 	return msg.Ack()
@@ -151,15 +272,26 @@ func (srv *TaskMux) Receive(msg Message) error {
 
 // ExecuteEvent with mux executor
 func (srv *TaskMux) ExecuteEvent(event Event) error {
-	task, ok := srv.tasks[event.Name()]
+	return srv.executeEvent(event, nil)
+}
+
+// executeEvent with the message of the stream which is held by the paused task
+func (srv *TaskMux) executeEvent(event Event, msg Message) error {
+	reg := srv.tasksRegistry()
+	task, ok := reg.tasks[event.Name()]
 	isFailover := false
 	if !ok {
 		isFailover = true
-		task = srv.failoverTask
+		task = reg.failoverTask
 	}
 	if task == nil {
 		return nil
 	}
+	if held, err := srv.holdEvent(event, msg); held {
+		return err
+	}
+	srv.control.inflight.Add(1)
+	defer srv.control.inflight.Add(-1)
 
 	startTime := time.Now()
 	event.SetPromise(task)
@@ -183,43 +315,111 @@ func (srv *TaskMux) ExecuteEvent(event Event) error {
 	}
 
 	ctx := srv.newExecContext()
-	wrt := srv.borrowResponseWriter(ctx, task, event)
+	if bp, _ := event.Payload().(*blobPayload); bp != nil {
+		bp.setContext(ctx)
+	}
+	if !isFailover {
+		srv.trackBegin(event)
+	}
 
-	// Execute the task
-	err := task.Task().Execute(ctx, event, wrt)
+	// Execute the task if the payload matches the schema
+	err := srv.validateInput(task, event)
+	if err == nil {
+		wrt := srv.borrowResponseWriter(ctx, task, event)
+		err = srv.executeTask(ctx, task, event, wrt, isFailover)
+	} else {
+		srv.afterExecute(task, event, isFailover, err)
+	}
 	if srv.cluster != nil {
 		_ = srv.cluster.ExecEvent(isFailover, event, time.Since(startTime), err)
 	}
 
 	if err != nil {
-		if srv.errorHandler != nil {
+		var validationErr *ValidationError
+		switch {
+		case srv.validationErrorHandler != nil && errors.As(err, &validationErr):
+			srv.validationErrorHandler(task.Task(), event, validationErr)
+		case srv.errorHandler != nil:
 			srv.errorHandler(task.Task(), event, err)
-		} else {
+		default:
 			return err
 		}
 	}
 	return nil
 }
 
-// FinishInit of the task server
+// executeTask and process the result after the finish of the task (including async tasks)
+func (srv *TaskMux) executeTask(ctx context.Context, task Promise, event Event, wrt ResponseWriter, isFailover bool) error {
+	if asyncTask, ok := task.Task().(*AsyncTask); ok {
+		return asyncTask.executeAsync(ctx, event, wrt, srv, task, isFailover)
+	}
+	err := task.Task().Execute(ctx, event, wrt)
+	srv.afterExecute(task, event, isFailover, err)
+	return err
+}
+
+// afterExecute tracks the end of the task execution
+func (srv *TaskMux) afterExecute(task Promise, event Event, isFailover bool, err error) {
+	if !isFailover {
+		srv.trackDone(event, err)
+	}
+	if err != nil && !errors.Is(err, ErrSkipEvent) {
+		srv.replyError(srv.newExecContext(), task, event, err)
+	}
+}
+
+// FinishInit of the task server.
+// It returns error if the task graph is invalid, warnings are logged.
+// The invalid graph is not registered in the cluster and control commands are not watched.
 func (srv *TaskMux) FinishInit() error {
+	diagnostics := srv.Validate()
+	for _, d := range diagnostics {
+		if d.Severity == graph.SeverityWarning {
+			log.Printf("task graph %s", d.String())
+		}
+	}
+	if err := diagnostics.Err(); err != nil {
+		return err
+	}
+	srv.inited.Store(true)
 	if srv.cluster != nil {
-		return srv.cluster.RegisterApplication(
+		err := srv.cluster.RegisterApplication(
 			srv.newExecContext(), srv)
+		if err != nil {
+			return err
+		}
 	}
+	srv.watchControls(srv.newExecContext())
 	return nil
 }
 
+// Validate the task graph of the mux: cycles, dangling external parents,
+// tasks without entry point and duplicate anonymous steps.
+// The merged cluster graph is checked too if the cluster reader is defined.
+func (srv *TaskMux) Validate() graph.Diagnostics {
+	diagnostics := srv.TaskGraph().Validate(false)
+	switch cluster := srv.cluster.(type) {
+	case interface {
+		validateGraph(*TaskMux) graph.Diagnostics
+	}:
+		diagnostics = diagnostics.Merge(cluster.validateGraph(srv))
+	case interface{ ValidateGraph() graph.Diagnostics }:
+		diagnostics = diagnostics.Merge(cluster.ValidateGraph())
+	}
+	return diagnostics
+}
+
 // Close task schedule and all subtasks
 func (srv *TaskMux) Close() error {
+	if srv == nil {
+		return nil
+	}
+	srv.stopControls()
 	if srv.cluster != nil {
 		_ = srv.cluster.UnregisterApplication()
 	}
 	var err error
-	if srv == nil || srv.tasks == nil {
-		return nil
-	}
-	for _, promise := range srv.tasks {
+	for _, promise := range srv.tasksRegistry().tasks {
 		if closer, ok := promise.(io.Closer); ok {
 			err = multierr.Append(err, closer.Close())
 		}
@@ -257,16 +457,82 @@ func (srv *TaskMux) CompleteTasks(event Event) (totalTasks, completedTasks []str
 	for tname := range allPossibleTasks {
 		totalTasks = append(totalTasks, tname)
 	}
+	sort.Strings(totalTasks)
+
+	// Tasks of the other branches of the chain are known only by the completion store
+	var status *EventStatus
+	if srv.completion != nil {
+		status, _ = srv.completion.store.EventStatus(event.ID().String())
+	}
+	for _, tname := range totalTasks {
+		switch {
+		case status != nil && slices.Contains(status.Tasks, tname):
+		case event.HasDoneTask(tname):
+		case event.IsComplete() && tname == event.Name():
+		default:
+			continue
+		}
+		completedTasks = append(completedTasks, tname)
+	}
 	return totalTasks, completedTasks
 }
 
 func (srv *TaskMux) borrowResponseWriter(ctx context.Context, prom Promise, event Event) ResponseWriter {
 	if srv.responseFactory == nil {
-		return &responseProxyWriter{mux: srv, event: event, promise: prom}
+		if srv.proxyWriters == nil {
+			return &responseProxyWriter{mux: srv, event: event, promise: prom}
+		}
+		return srv.proxyWriters.Borrow(ctx, prom, event)
 	}
 	return srv.responseFactory.Borrow(ctx, prom, event)
 }
 
+// responsePayload wraps the response value with the codec of the task or the mux
+func (srv *TaskMux) responsePayload(prom Promise, value any) any {
+	if _, ok := value.(Payload); ok {
+		return value
+	}
+	var c Codec
+	if p, _ := prom.(*promise); p != nil {
+		c = p.options.Codec
+	}
+	if c == nil && srv != nil {
+		c = srv.defaultCodec
+	}
+	if c == nil {
+		return value
+	}
+	return PayloadWithCodec(value, c)
+}
+
+// envelope returns options of the event encoding
+func (srv *TaskMux) envelope() *envelopeOptions {
+	if srv == nil {
+		return nil
+	}
+	return srv.envelopeOpts
+}
+
+// encodedPayload sends payload size metrics to the cluster monitor
+func (srv *TaskMux) encodedPayload(event Event, rawSize, encodedSize int) {
+	if srv == nil || srv.cluster == nil {
+		return
+	}
+	if cl, ok := srv.cluster.(interface {
+		EncodeEvent(event Event, rawSize, encodedSize int) error
+	}); ok {
+		_ = cl.EncodeEvent(event, rawSize, encodedSize)
+	}
+}
+
+// eventPool of the in-process response events if the pooling is enabled
+func (srv *TaskMux) eventPool() *sync.Pool {
+	if srv == nil || !srv.eventPooling {
+		return nil
+	}
+	return &eventPool
+}
+
 func (srv *TaskMux) newExecContext() context.Context {
 	ctx := srv.mainExecContext
 	if ctx == nil {
@@ -278,9 +544,14 @@ func (srv *TaskMux) newExecContext() context.Context {
 	return ctx
 }
 
+// hasExternalTargets returns true if tasks can be linked outside of the promise chains
+func (srv *TaskMux) hasExternalTargets() bool {
+	return len(srv.tasksRegistry().hiddenTaskMapping) > 0 || srv.cluster != nil
+}
+
 func (srv *TaskMux) targetEventsAfter(eventName string) []string {
-	if srv.hiddenTaskMapping != nil && len(srv.hiddenTaskMapping[eventName]) > 0 {
-		return srv.hiddenTaskMapping[eventName]
+	if targets := srv.tasksRegistry().hiddenTaskMapping[eventName]; len(targets) > 0 {
+		return targets
 	}
 	if srv.cluster != nil {
 		return srv.cluster.TargetEventsAfter(eventName)
@@ -288,24 +559,53 @@ func (srv *TaskMux) targetEventsAfter(eventName string) []string {
 	return nil
 }
 
+// Cluster returns the cluster extension of the mux or nil
+func (srv *TaskMux) Cluster() ClusterExt {
+	return srv.cluster
+}
+
 // TaskMap returns linked list of events
 func (srv *TaskMux) TaskMap() map[string][]string {
-	mp := make(map[string][]string, len(srv.tasks)+len(srv.hiddenTaskMapping))
-	if srv.tasks != nil {
-		for eventName, promiseObject := range srv.tasks {
-			mp[eventName] = mergeStrArr(mp[eventName], promiseObject.TargetEventName())
-		}
+	reg := srv.tasksRegistry()
+	mp := make(map[string][]string, len(reg.tasks)+len(reg.hiddenTaskMapping))
+	for eventName, promiseObject := range reg.tasks {
+		mp[eventName] = mergeStrArr(mp[eventName], promiseObject.TargetEventName())
 	}
-
-	if srv.hiddenTaskMapping != nil {
-		for eventName, targetEvent := range srv.hiddenTaskMapping {
-			mp[eventName] = mergeStrArr(mp[eventName], targetEvent)
-		}
+	for eventName, targetEvent := range reg.hiddenTaskMapping {
+		mp[eventName] = mergeStrArr(mp[eventName], targetEvent)
 	}
 
 	return mp
 }
 
+// TaskGraph returns graph of the mux tasks
+func (srv *TaskMux) TaskGraph() *graph.Graph {
+	return graph.FromApplication(&monitor.ApplicationInfo{
+		Tasks:   srv.TaskMap(),
+		Schemas: srv.TaskSchemas(),
+	})
+}
+
+// TaskSchemas returns payload schemas of the tasks
+func (srv *TaskMux) TaskSchemas() map[string]*monitor.TaskSchema {
+	schemas := map[string]*monitor.TaskSchema{}
+	for eventName, promiseObject := range srv.tasksRegistry().tasks {
+		p, _ := promiseObject.(*promise)
+		if p == nil || (p.options.InputSchema == nil && p.options.OutputSchema == nil) {
+			continue
+		}
+		taskSchema := &monitor.TaskSchema{}
+		if p.options.InputSchema != nil {
+			taskSchema.Input, _ = p.options.InputSchema.MarshalJSON()
+		}
+		if p.options.OutputSchema != nil {
+			taskSchema.Output, _ = p.options.OutputSchema.MarshalJSON()
+		}
+		schemas[eventName] = taskSchema
+	}
+	return schemas
+}
+
 func prepareTaskName(name string) (parent, target string) {
 	splitName := strings.SplitN(name, ">", 2)
 	if len(splitName) > 1 {
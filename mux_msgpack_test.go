@@ -0,0 +1,40 @@
+//go:build !apnemsgpack
+
+package asyncp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/codec"
+	"github.com/demdxx/asyncp/v2/schema"
+)
+
+func TestMuxCodec(t *testing.T) {
+	var (
+		pub = &testRecordPublisher{}
+		mux = NewTaskMux(
+			WithStreamResponsePublisher(pub),
+			WithDefaultCodec(codec.MsgPack),
+		)
+	)
+	mux.Handle(`test`, func(s string) string { return s })
+	mux.Handle(`gob`, func(s string) string { return s }, WithCodec(codec.Gob))
+	assert.NoError(t, mux.ExecuteEvent(WithPayload(`test`, `msgpack`)))
+	assert.NoError(t, mux.ExecuteEvent(WithPayload(`gob`, `gob`)))
+	if assert.Len(t, pub.events, 2) {
+		assert.Equal(t, `application/msgpack`, payloadContentType(pub.events[0].Payload()))
+		assert.Equal(t, `application/x-gob`, payloadContentType(pub.events[1].Payload()))
+	}
+}
+
+func TestValidatePayloadCodec(t *testing.T) {
+	sch := schema.MustParse([]byte(`{"type":"object","required":["id"]}`))
+	value := map[string]any{"title": "no id"}
+	data, err := codec.MsgPack.Marshal(value)
+	assert.NoError(t, err)
+	assert.NoError(t, validatePayload(sch, dataPayload{bytes: data, codec: codec.MsgPack}),
+		"encoded payloads of not JSON codecs are not validated")
+	assert.ErrorIs(t, validatePayload(sch, &valuePayload{value: value, codec: codec.MsgPack}), ErrValidation)
+}
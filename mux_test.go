@@ -6,6 +6,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/graph"
+	"github.com/demdxx/asyncp/v2/schema"
 )
 
 func TestMuxErrorPanic(t *testing.T) {
@@ -44,3 +47,109 @@ func TestMuxErrorPanic(t *testing.T) {
 	assert.ElementsMatch(t, []string{`error`}, totalTasks)
 	assert.ElementsMatch(t, []string{}, completeTasks)
 }
+
+func TestMuxValidation(t *testing.T) {
+	type item struct {
+		ID    int    `json:"id" jsonschema:"required"`
+		Title string `json:"title,omitempty"`
+	}
+	var (
+		rejected *ValidationError
+		executed = 0
+		mux      = NewTaskMux(
+			WithValidationErrorHandler(func(_ Task, _ Event, err *ValidationError) { rejected = err }),
+		)
+	)
+	mux.Handle(`test`, func(it item) { executed++ }, WithInputValidation())
+	Handle(mux, `typed`, func(_ context.Context, it *item) (int, error) {
+		return it.ID, nil
+	}, WithInputValidation(), WithOutputSchema(schema.MustParse([]byte(`{"type":"string"}`))))
+
+	assert.NoError(t, mux.ExecuteEvent(WithPayload(`test`, []byte(`{"id":1}`))))
+	assert.Equal(t, 1, executed)
+	assert.Nil(t, rejected)
+
+	assert.NoError(t, mux.ExecuteEvent(WithPayload(`test`, []byte(`{"id":"1"}`))))
+	assert.Equal(t, 1, executed)
+	assert.NotNil(t, rejected)
+
+	muxNoHandler := NewTaskMux()
+	muxNoHandler.Handle(`test`, func(it item) {}, WithInputValidation())
+	err := muxNoHandler.ExecuteEvent(WithPayload(`test`, map[string]any{"title": "no id"}))
+	assert.ErrorIs(t, err, ErrValidation)
+
+	rejected = nil
+	assert.NoError(t, mux.ExecuteEvent(WithPayload(`typed`, item{ID: 1})))
+	assert.NotNil(t, rejected, "output payload must be rejected")
+}
+
+func newBenchmarkMux(tb testing.TB) (*TaskMux, Message) {
+	mux := NewTaskMux(WithEventPooling())
+	mux.Handle("test", FuncTask(func(ctx context.Context, event Event, rw ResponseWriter) error {
+		return rw.WriteResonse(event.Payload())
+	})).Then(FuncTask(func(ctx context.Context, event Event, rw ResponseWriter) error {
+		return nil
+	}))
+	ev := WithPayload("test", map[string]any{"text": "message"})
+	ev.After(WithPayload("prev", nil))
+	data, err := ev.Encode()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return mux, message(data)
+}
+
+func TestMuxReceiveAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable in race mode")
+	}
+	mux, msg := newBenchmarkMux(t)
+	assert.NoError(t, mux.Receive(msg))
+	// Only the payload data and its wrapper are allocated for every message
+	allocs := testing.AllocsPerRun(100, func() { _ = mux.Receive(msg) })
+	assert.LessOrEqual(t, allocs, 2.0, "allocations per message")
+}
+
+func BenchmarkMuxReceive(b *testing.B) {
+	mux, msg := newBenchmarkMux(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := mux.Receive(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMuxReceiveTyped(b *testing.B) {
+	type item struct {
+		Text string `json:"text"`
+	}
+	var (
+		mux    = NewTaskMux(WithEventPooling())
+		_, msg = newBenchmarkMux(b)
+	)
+	Handle(mux, "test", func(ctx context.Context, it item) (*item, error) { return nil, nil })
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := mux.Receive(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMuxValidate(t *testing.T) {
+	mux := NewTaskMux()
+	mux.Handle("rss", func(s string) string { return s }).Then(func(s string) {})
+	mux.Handle("video>thumbs", func(s string) {})
+	assert.NoError(t, mux.FinishInit())
+	if diagnostics := mux.Validate(); assert.Len(t, diagnostics, 1) {
+		assert.Equal(t, graph.DiagnosticDanglingParent, diagnostics[0].Kind)
+		assert.Equal(t, []string{"video"}, diagnostics[0].Tasks)
+	}
+
+	mux.Handle("a>b", func(s string) string { return s })
+	mux.Handle("b>a", func(s string) string { return s })
+	err := mux.FinishInit()
+	assert.ErrorContains(t, err, "tasks are linked in cycle [a, b]")
+	assert.ErrorContains(t, err, "tasks can't be reached from any entry point [a, b]")
+}
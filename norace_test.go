@@ -0,0 +1,5 @@
+//go:build !race
+
+package asyncp
+
+const raceEnabled = false
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"os"
+	"time"
 
 	"github.com/demdxx/asyncp/v2/monitor"
 )
@@ -19,13 +20,54 @@ type (
 
 // Options of the mux server
 type Options struct {
-	MainExecContext context.Context
-	PanicHandler    PanicHandlerFnk
-	ErrorHandler    ErrorHandlerFnk
-	ContextWrapper  ContextWrapperFnk
-	ResponseFactory ResponseWriterFactory
-	Cluster         ClusterExt
-	EventAllocator  EventAllocator
+	MainExecContext        context.Context
+	PanicHandler           PanicHandlerFnk
+	ErrorHandler           ErrorHandlerFnk
+	ValidationErrorHandler ValidationErrorHandlerFnk
+	ContextWrapper         ContextWrapperFnk
+	ResponseFactory        ResponseWriterFactory
+	Cluster                ClusterExt
+	EventAllocator         EventAllocator
+	DefaultCodec           Codec
+
+	// Compression of the payloads larger then CompressMinSize
+	Compression     Compressor
+	CompressMinSize int
+
+	// Encryption of the payloads
+	Encryption Encryptor
+
+	// BlobStore for the payloads larger then BlobThreshold (claim-check)
+	BlobStore     BlobStore
+	BlobThreshold int
+	BlobTTL       time.Duration
+
+	// CloudEvents encoding of the events
+	CloudEvents *CloudEventsOptions
+
+	// EnvelopeVersion of the produced events
+	EnvelopeVersion int
+
+	// ReplyPublisher of the chain results for the requests
+	ReplyPublisher Publisher
+
+	// CompletionStore tracks processing of the root events
+	CompletionStore CompletionStore
+	OnComplete      CompleteHandlerFnk
+	OnFailed        FailedHandlerFnk
+
+	// Journal of the received and emitted events
+	Journal Journal
+
+	// ControlStore of the cluster-wide task control watched with ControlInterval
+	ControlStore    ControlStore
+	ControlInterval time.Duration
+
+	// PauseBufferSize of the events held by every paused task
+	PauseBufferSize int
+
+	// EventPooling reuses received and in-process events after the processing
+	EventPooling bool
 }
 
 func (opt *Options) _eventAllocator() EventAllocator {
@@ -59,6 +101,13 @@ func WithErrorHandler(h ErrorHandlerFnk) Option {
 	}
 }
 
+// WithValidationErrorHandler puts handler of the payloads rejected by the schema to the Mux option
+func WithValidationErrorHandler(h ValidationErrorHandlerFnk) Option {
+	return func(opt *Options) {
+		opt.ValidationErrorHandler = h
+	}
+}
+
 // WithContextWrapper puts context wrapper to the Mux option
 func WithContextWrapper(w ContextWrapperFnk) Option {
 	return func(opt *Options) {
@@ -120,6 +169,122 @@ func WithEventAllocator(eventAllocator EventAllocator) Option {
 	}
 }
 
+// WithDefaultCodec set option with codec of the response payloads
+func WithDefaultCodec(c Codec) Option {
+	return func(opt *Options) {
+		opt.DefaultCodec = c
+	}
+}
+
+// WithCompression set option with compression of the payloads
+// which are larger then minSize bytes
+func WithCompression(c Compressor, minSize int) Option {
+	return func(opt *Options) {
+		opt.Compression = c
+		opt.CompressMinSize = minSize
+	}
+}
+
+// WithEncryption set option with encryption of the payloads
+func WithEncryption(enc Encryptor) Option {
+	return func(opt *Options) {
+		opt.Encryption = enc
+	}
+}
+
+// WithClaimCheck stores payloads larger then threshold in the blob store
+// and sends only the reference through the message broker.
+// Blobs of the chain are removed together when TTL runs out or, if the completion
+// is tracked and the store is blobstore.PrefixDeleter, after the end of the whole chain.
+// Zero TTL means DefaultBlobTTL.
+func WithClaimCheck(store BlobStore, threshold int, ttl time.Duration) Option {
+	return func(opt *Options) {
+		opt.BlobStore = store
+		opt.BlobThreshold = threshold
+		opt.BlobTTL = ttl
+	}
+}
+
+// WithCloudEvents set option with CloudEvents encoding of the produced events
+func WithCloudEvents(opts CloudEventsOptions) Option {
+	return func(opt *Options) {
+		opt.CloudEvents = &opts
+	}
+}
+
+// WithEnvelopeVersion set option with version of the produced event envelopes.
+// Version 1 is used by default, enable version 2 when all consumers are upgraded.
+func WithEnvelopeVersion(version int) Option {
+	return func(opt *Options) {
+		opt.EnvelopeVersion = version
+	}
+}
+
+// WithReplyPublisher set option with the stream of the chain results for the requests.
+// The response stream is used by default.
+func WithReplyPublisher(pub Publisher) Option {
+	return func(opt *Options) {
+		opt.ReplyPublisher = pub
+	}
+}
+
+// WithCompletionStore set option with the store of the root event processing state.
+// Use the shared store like kvstorage.CompletionStore to track chains of the cluster.
+func WithCompletionStore(store CompletionStore) Option {
+	return func(opt *Options) {
+		opt.CompletionStore = store
+	}
+}
+
+// WithOnComplete set option with the handler of the finished chains.
+// The memory completion store is used if other is not defined.
+func WithOnComplete(h CompleteHandlerFnk) Option {
+	return func(opt *Options) {
+		opt.OnComplete = h
+	}
+}
+
+// WithOnFailed set option with the handler of the chains finished with errors.
+// The memory completion store is used if other is not defined.
+func WithOnFailed(h FailedHandlerFnk) Option {
+	return func(opt *Options) {
+		opt.OnFailed = h
+	}
+}
+
+// WithJournal set option with the journal of the received and emitted events
+func WithJournal(j Journal) Option {
+	return func(opt *Options) {
+		opt.Journal = j
+	}
+}
+
+// WithControl set option with the store of the cluster-wide pause, resume and drain commands.
+// The store is watched with the interval after FinishInit until the mux is closed.
+func WithControl(store ControlStore, interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.ControlStore = store
+		opt.ControlInterval = interval
+	}
+}
+
+// WithPauseBufferSize set option with the count of the events held by every paused task,
+// new events of the full buffer are returned with ErrTaskPaused
+func WithPauseBufferSize(size int) Option {
+	return func(opt *Options) {
+		opt.PauseBufferSize = size
+	}
+}
+
+// WithEventPooling set option of the event reusing after the processing.
+// Handlers must not use the event or its done tasks after the return
+// without RetainEvent.
+func WithEventPooling() Option {
+	return func(opt *Options) {
+		opt.EventPooling = true
+	}
+}
+
 func localIP() string {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
@@ -1,10 +1,14 @@
 package asyncp
 
 import (
-	"encoding/json"
 	"reflect"
+
+	"github.com/demdxx/asyncp/v2/codec"
 )
 
+// Codec describes payload serialization format
+type Codec = codec.Codec
+
 // Payload represents interface of working with input data
 type Payload interface {
 	// Encode payload data to the bytes
@@ -14,6 +18,48 @@ type Payload interface {
 	Decode(target any) error
 }
 
+// DecodePayload into the value of the specific type.
+// In-process payloads of the same type are returned without any decoding,
+// the pointer type receives the pointer to the copy of the value.
+func DecodePayload[T any](payload Payload) (T, error) {
+	var val T
+	switch p := payload.(type) {
+	case nil:
+		return val, nil
+	case *valuePayload:
+		switch v := p.value.(type) {
+		case T:
+			return v, nil
+		case *T:
+			if v != nil {
+				return *v, nil
+			}
+			return val, nil
+		}
+	}
+	err := payload.Decode(&val)
+	return val, err
+}
+
+// PayloadWithCodec returns payload which will be encoded by the codec.
+// Byte slice value is considered as the data already encoded by the codec.
+func PayloadWithCodec(value any, c Codec) Payload {
+	switch b := value.(type) {
+	case []byte:
+		return dataPayload{bytes: b, codec: c}
+	default:
+		return &valuePayload{value: value, codec: c}
+	}
+}
+
+// payloadContentType returns content type of the payload encoding
+func payloadContentType(payload Payload) string {
+	if ct, ok := payload.(interface{ ContentType() string }); ok {
+		return ct.ContentType()
+	}
+	return codec.JSON.ContentType()
+}
+
 func newPayload(val any) (Payload, error) {
 	switch b := (val).(type) {
 	case nil:
@@ -27,33 +73,60 @@ func newPayload(val any) (Payload, error) {
 
 type dataPayload struct {
 	bytes []byte
+	codec Codec
 }
 
 func (p dataPayload) Decode(target any) error {
-	return json.Unmarshal(p.bytes, target)
+	return codec.Or(p.codec).Unmarshal(p.bytes, target)
 }
 
 func (p dataPayload) Encode() ([]byte, error) {
 	return p.bytes, nil
 }
 
+func (p dataPayload) ContentType() string {
+	return codec.Or(p.codec).ContentType()
+}
+
 func (p dataPayload) MarshalJSON() ([]byte, error) {
 	return p.Encode()
 }
 
 type valuePayload struct {
 	value any
+	codec Codec
 }
 
 func (p *valuePayload) Decode(target any) error {
 	dst := valueFinal(reflect.ValueOf(target))
 	src := valueFinal(reflect.ValueOf(p.value))
-	dst.Set(src)
-	return nil
+	if src.IsValid() && dst.CanSet() {
+		switch {
+		case src.Type().AssignableTo(dst.Type()):
+			dst.Set(src)
+			return nil
+		case dst.Kind() == reflect.Pointer && src.Type().AssignableTo(dst.Type().Elem()):
+			// Nil pointer target gets the pointer to the copy of the value
+			ptr := reflect.New(dst.Type().Elem())
+			ptr.Elem().Set(src)
+			dst.Set(ptr)
+			return nil
+		}
+	}
+	// Types are not compatible so convert the value through the encoding
+	data, err := p.Encode()
+	if err != nil {
+		return err
+	}
+	return codec.Or(p.codec).Unmarshal(data, target)
 }
 
 func (p *valuePayload) Encode() ([]byte, error) {
-	return json.Marshal(p.value)
+	return codec.Or(p.codec).Marshal(p.value)
+}
+
+func (p *valuePayload) ContentType() string {
+	return codec.Or(p.codec).ContentType()
 }
 
 func (p *valuePayload) MarshalJSON() ([]byte, error) {
@@ -66,3 +139,15 @@ func valueFinal(v reflect.Value) reflect.Value {
 	}
 	return v
 }
+
+// isNilValue checks the value without panic for types which can't be nil
+func isNilValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package asyncp
 import (
 	"fmt"
 	"io"
+	"sync/atomic"
 )
 
 // Promise describe the behaviour of Single task item
@@ -47,8 +48,8 @@ type promise struct {
 	// Accept event with name
 	currentEventName string
 
-	// Writing target name
-	targetEventName []string
+	// Writing target name, the registered promise is replaced by the copy with new targets
+	targetEventName atomic.Pointer[[]string]
 
 	// Map the task after the event
 	afterEventName string
@@ -61,6 +62,9 @@ type promise struct {
 
 	// Execution task object
 	task Task
+
+	// Task options of the response processing
+	options TaskOptions
 }
 
 func newPoromise(mux *TaskMux, parent Promise, name string, task Task, anonymous bool) *promise {
@@ -73,12 +77,43 @@ func newPoromise(mux *TaskMux, parent Promise, name string, task Task, anonymous
 	}
 }
 
+// clone of the promise for the changes of the registry
+func (prom *promise) clone() *promise {
+	p := &promise{
+		anonymous:        prom.anonymous,
+		currentEventName: prom.currentEventName,
+		afterEventName:   prom.afterEventName,
+		parent:           prom.parent,
+		mux:              prom.mux,
+		task:             prom.task,
+		options:          prom.options,
+	}
+	p.targetEventName.Store(prom.targetEventName.Load())
+	return p
+}
+
+// current version of the promise in the published registry
+func (prom *promise) current() *promise {
+	if prom.mux == nil {
+		return prom
+	}
+	if p, _ := prom.mux.tasksRegistry().tasks[prom.currentEventName].(*promise); p != nil {
+		return p
+	}
+	return prom
+}
+
 func (prom *promise) EventName() string {
 	return prom.currentEventName
 }
 
 func (prom *promise) TargetEventName() []string {
-	if len(prom.targetEventName) == 0 {
+	prom = prom.current()
+	targets := prom.targets()
+	if len(targets) == 0 {
+		if !prom.mux.hasExternalTargets() {
+			return nil
+		}
 		prProm, eventName, _ := prom.originalEventName()
 		if eventName != `` && !prProm.IsVirtual() {
 			// Find the target after global event which is starts from `@`
@@ -89,7 +124,7 @@ func (prom *promise) TargetEventName() []string {
 		}
 		return prom.mux.targetEventsAfter(prom.EventName())
 	}
-	return prom.targetEventName
+	return targets
 }
 
 func (prom *promise) AfterEventName() string {
@@ -105,12 +140,35 @@ func (prom *promise) TargetEvent(name string) Promise {
 }
 
 func (prom *promise) Then(handler any) Promise {
-	p := prom.mux.handleExt(prom.EventName()+">"+prom.genTargetEvent(), handler, true)
+	return prom.then(handler)
+}
+
+func (prom *promise) then(handler any, options ...TaskOption) (p Promise) {
+	mux := prom.mux
+	_ = mux.updateTasks(func(reg *taskRegistry) error {
+		p = mux.registerTask(reg, prom.EventName()+">"+reg.promise(prom).genTargetEvent(), handler, true, options...)
+		return nil
+	})
 	return p
 }
 
 func (prom *promise) ThenEvent(name string) {
-	prom.targetEventName = []string{name}
+	if prom.mux == nil {
+		prom.targetEventName.Store(&[]string{name})
+		return
+	}
+	_ = prom.mux.updateTasks(func(reg *taskRegistry) error {
+		reg.linkTarget(prom, name)
+		return nil
+	})
+}
+
+// targets of the promise without external links
+func (prom *promise) targets() []string {
+	if names := prom.targetEventName.Load(); names != nil {
+		return *names
+	}
+	return nil
 }
 
 func (prom *promise) Parent() Promise {
@@ -118,12 +176,10 @@ func (prom *promise) Parent() Promise {
 }
 
 func (prom *promise) LastPromise() Promise {
-	for _, name := range prom.targetEventName {
-		if task := prom.mux.tasks[name]; task != nil {
-			return task.LastPromise()
-		}
+	if prom.mux == nil {
+		return prom
 	}
-	return prom
+	return prom.mux.tasksRegistry().lastPromise(prom.current())
 }
 
 func (prom *promise) IsAnonymous() bool {
@@ -173,16 +229,14 @@ func (prom *promise) originalEventName() (Promise, string, int) {
 
 // generate event name after the current one
 func (prom *promise) genTargetEvent() string {
-	if len(prom.targetEventName) == 0 {
-		_, name, depth := prom.originalEventName()
-		if depth > 1 {
-			name = fmt.Sprintf(`%s.%d`, name, depth)
-		} else {
-			name = fmt.Sprintf(`%s.1`, prom.EventName())
-		}
-		prom.ThenEvent(name)
+	if targets := prom.targets(); len(targets) > 0 {
+		return targets[0]
+	}
+	_, name, depth := prom.originalEventName()
+	if depth > 1 {
+		return fmt.Sprintf(`%s.%d`, name, depth)
 	}
-	return prom.targetEventName[0]
+	return fmt.Sprintf(`%s.1`, prom.EventName())
 }
 
 // IsVirtual promise type
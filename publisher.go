@@ -2,6 +2,7 @@ package asyncp
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/geniusrabbit/notificationcenter/v2"
 )
@@ -76,8 +77,9 @@ func (wr *publisherEventWrapper) SetMux(mux *TaskMux) {
 func (wr *publisherEventWrapper) Publish(ctx context.Context, messages ...any) error {
 	events := make([]any, 0, len(messages))
 	for _, msg := range messages {
-		event := WithPayload(wr.name, msg)
+		event := WithPayload(wr.name, wr.mux.responsePayload(nil, msg)).(*event)
 		event.SetMux(wr.mux)
+		event.markPublished()
 		events = append(events, event)
 	}
 	return wr.pub.Publish(ctx, events...)
@@ -88,8 +90,9 @@ func (wr *publisherEventWrapper) PublishAndReturnIDs(ctx context.Context, messag
 	events := make([]any, 0, len(messages))
 	ids := make([]string, 0, len(messages))
 	for _, msg := range messages {
-		event := WithPayload(wr.name, msg)
+		event := WithPayload(wr.name, wr.mux.responsePayload(nil, msg)).(*event)
 		event.SetMux(wr.mux)
+		event.markPublished()
 		events = append(events, event)
 		ids = append(ids, event.ID().String())
 	}
@@ -98,3 +101,31 @@ func (wr *publisherEventWrapper) PublishAndReturnIDs(ctx context.Context, messag
 	}
 	return ids, nil
 }
+
+type compressionPublisher struct {
+	pub        Publisher
+	compressor Compressor
+	minSize    int
+}
+
+// PublisherWithCompression wraps publisher with compression of the event payloads
+// which are larger then minSize bytes. It overrides compression options of the mux.
+func PublisherWithCompression(publisher Publisher, c Compressor, minSize int) Publisher {
+	return &compressionPublisher{pub: publisher, compressor: c, minSize: minSize}
+}
+
+// Publish events with compressed payloads
+func (wr *compressionPublisher) Publish(ctx context.Context, messages ...any) error {
+	msgs := make([]any, 0, len(messages))
+	for _, msg := range messages {
+		if ev, ok := msg.(*event); ok {
+			data, err := ev.encode(ev.mux.envelope().withCompression(wr.compressor, wr.minSize))
+			if err != nil {
+				return err
+			}
+			msg = json.RawMessage(data)
+		}
+		msgs = append(msgs, msg)
+	}
+	return wr.pub.Publish(ctx, msgs...)
+}
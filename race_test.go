@@ -0,0 +1,6 @@
+//go:build race
+
+package asyncp
+
+// raceEnabled disables allocation tests, sync.Pool drops objects in race mode
+const raceEnabled = true
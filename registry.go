@@ -0,0 +1,153 @@
+package asyncp
+
+import (
+	"log"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// taskRegistry of the mux tasks which is never changed after the publishing
+type taskRegistry struct {
+	// Chanel name + task with responser
+	tasks map[string]Promise
+
+	// Maps final task of the chanel with tasks from other chanels or clusters.
+	// All linked external events starts from `@`; @globalEvent -> targetEvent
+	hiddenTaskMapping map[string][]string
+
+	// Default task if not found
+	failoverTask Promise
+}
+
+func newTaskRegistry() *taskRegistry {
+	return &taskRegistry{
+		tasks:             map[string]Promise{},
+		hiddenTaskMapping: map[string][]string{},
+	}
+}
+
+// clone of the registry for the changes
+func (reg *taskRegistry) clone() *taskRegistry {
+	return &taskRegistry{
+		tasks:             maps.Clone(reg.tasks),
+		hiddenTaskMapping: maps.Clone(reg.hiddenTaskMapping),
+		failoverTask:      reg.failoverTask,
+	}
+}
+
+// promise returns the version of the promise in the registry
+func (reg *taskRegistry) promise(prom *promise) *promise {
+	if p, _ := reg.tasks[prom.EventName()].(*promise); p != nil {
+		return p
+	}
+	return prom
+}
+
+// linkTarget replaces the promise by the copy with the target event,
+// so the published registry is never changed
+func (reg *taskRegistry) linkTarget(prom *promise, name string) *promise {
+	p := reg.promise(prom).clone()
+	p.targetEventName.Store(&[]string{name})
+	reg.tasks[p.EventName()] = p
+	return p
+}
+
+// unlinkTargets replaces the promise by the copy without targets
+func (reg *taskRegistry) unlinkTargets(prom *promise) {
+	p := reg.promise(prom).clone()
+	p.targetEventName.Store(nil)
+	reg.tasks[p.EventName()] = p
+}
+
+// lastPromise of the chain in the registry
+func (reg *taskRegistry) lastPromise(prom *promise) Promise {
+	for _, name := range prom.targets() {
+		if next, _ := reg.tasks[name].(*promise); next != nil {
+			return reg.lastPromise(next)
+		}
+	}
+	return prom
+}
+
+// removeTask unlinks the task from the parent and removes it with anonymous steps of the chain.
+// The task can't be removed while the named tasks are linked after it.
+func (reg *taskRegistry) removeTask(prom Promise) ([]Promise, error) {
+	chain := reg.chain(prom)
+	for _, p := range chain {
+		step, _ := p.(*promise)
+		if step == nil {
+			continue
+		}
+		for _, name := range step.targets() {
+			if next := reg.tasks[name]; next != nil && !slices.Contains(chain, next) {
+				return nil, errors.Wrap(ErrTaskLinked, name)
+			}
+		}
+	}
+	switch parent := prom.Parent(); {
+	case parent == nil:
+	case parent.IsVirtual():
+		key := "@" + parent.EventName()
+		targets := slices.DeleteFunc(slices.Clone(reg.hiddenTaskMapping[key]),
+			func(name string) bool { return name == prom.EventName() })
+		if len(targets) == 0 {
+			delete(reg.hiddenTaskMapping, key)
+		} else {
+			reg.hiddenTaskMapping[key] = targets
+		}
+	default:
+		if p, _ := reg.tasks[parent.EventName()].(*promise); p != nil && slices.Equal(p.targets(), []string{prom.EventName()}) {
+			reg.unlinkTargets(p)
+		}
+	}
+	for _, p := range chain {
+		delete(reg.tasks, p.EventName())
+	}
+	return chain, nil
+}
+
+// chain of the task with its anonymous steps
+func (reg *taskRegistry) chain(prom Promise) []Promise {
+	chain := []Promise{prom}
+	p, _ := prom.(*promise)
+	if p == nil {
+		return chain
+	}
+	for _, name := range p.targets() {
+		next := reg.tasks[name]
+		if next != nil && next.IsAnonymous() && next.Parent() != nil && next.Parent().EventName() == prom.EventName() {
+			chain = append(chain, reg.chain(next)...)
+		}
+	}
+	return chain
+}
+
+// tasksRegistry returns the current registry of the tasks
+func (srv *TaskMux) tasksRegistry() *taskRegistry {
+	if reg := srv.registry.Load(); reg != nil {
+		return reg
+	}
+	return emptyTaskRegistry
+}
+
+// updateTasks applies the change to the copy of the registry and publishes it.
+// After the initialisation the changed tasks are announced to the cluster.
+func (srv *TaskMux) updateTasks(change func(reg *taskRegistry) error) error {
+	srv.registryMx.Lock()
+	defer srv.registryMx.Unlock()
+	reg := srv.tasksRegistry().clone()
+	if err := change(reg); err != nil {
+		return err
+	}
+	srv.registry.Store(reg)
+	if srv.cluster != nil && srv.inited.Load() {
+		if err := srv.cluster.RegisterApplication(srv.newExecContext(), srv); err != nil {
+			log.Printf("register application: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+var emptyTaskRegistry = newTaskRegistry()
@@ -0,0 +1,155 @@
+package asyncp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/monitor"
+)
+
+type testAppRegistry struct {
+	mx    sync.Mutex
+	tasks []map[string][]string
+}
+
+func (r *testAppRegistry) RegisterApplication(appInfo *monitor.ApplicationInfo) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.tasks = append(r.tasks, appInfo.Tasks)
+	return nil
+}
+func (r *testAppRegistry) DeregisterApplication() error                               { return nil }
+func (r *testAppRegistry) ReceiveEvent(monitor.EventType) error                       { return nil }
+func (r *testAppRegistry) ExecuteTask(monitor.EventType, time.Duration) error         { return nil }
+func (r *testAppRegistry) ExecuteFailoverTask(monitor.EventType, time.Duration) error { return nil }
+
+func (r *testAppRegistry) lastTasks() map[string][]string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if len(r.tasks) == 0 {
+		return nil
+	}
+	return r.tasks[len(r.tasks)-1]
+}
+
+func TestMuxUnhandle(t *testing.T) {
+	mux := NewTaskMux()
+	mux.Handle("a", func(s string) string { return s }).
+		Then(func(s string) string { return s }).
+		Then(func(s string) {})
+	mux.Handle("a>b", func(s string) {})
+	mux.Handle("ext>c", func(s string) {})
+	mux.Handle("ext>d", func(s string) {})
+
+	assert.ErrorIs(t, mux.Unhandle("unknown"), ErrTaskNotFound)
+	assert.NoError(t, mux.Unhandle("b"))
+	assert.NoError(t, mux.Unhandle("c"))
+	assert.Equal(t, map[string][]string{
+		"a":    {"a.1"},
+		"a.1":  {"a.2"},
+		"a.2":  {},
+		"d":    {},
+		"@ext": {"d"},
+	}, mux.TaskMap())
+
+	assert.NoError(t, mux.Unhandle("a"))
+	assert.NoError(t, mux.Unhandle("d"))
+	assert.Empty(t, mux.TaskMap())
+
+	// The removed task can be registered again
+	mux.Handle("a", func(s string) {})
+	assert.Equal(t, map[string][]string{"a": {}}, mux.TaskMap())
+}
+
+func TestMuxHandlePublishedRegistry(t *testing.T) {
+	mux := NewTaskMux()
+	first := mux.Handle("a", func(s string) {})
+	published := mux.tasksRegistry()
+
+	// Published promises are replaced by copies with the new targets
+	mux.Handle("a>b", func(s string) {})
+	assert.Empty(t, published.tasks["a"].(*promise).targets())
+	assert.Equal(t, []string{"b"}, first.TargetEventName())
+	assert.Equal(t, []string{"b"}, mux.tasksRegistry().tasks["a"].(*promise).targets())
+
+	// Named tasks have to be removed before the parent
+	assert.ErrorIs(t, mux.Unhandle("a"), ErrTaskLinked)
+	assert.NoError(t, mux.Unhandle("b"))
+	assert.NoError(t, mux.Unhandle("a"))
+}
+
+func TestMuxUnhandleAsync(t *testing.T) {
+	var (
+		executed atomic.Int64
+		mux      = NewTaskMux()
+		task     = FuncTask(func(ctx context.Context, event Event, responseWriter ResponseWriter) error {
+			time.Sleep(time.Millisecond)
+			executed.Add(1)
+			return nil
+		})
+	)
+	mux.Handle("a", task.Async(WithWorkerCount(1)))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, mux.Receive(mustMessageFrom(WithPayload("a", "test"))))
+	}
+	// Queued events are processed before the close of the pool
+	assert.NoError(t, mux.Unhandle("a"))
+	assert.Equal(t, int64(10), executed.Load())
+
+	var nilMux *TaskMux
+	assert.NoError(t, nilMux.Close())
+}
+
+func TestMuxHandleConcurrent(t *testing.T) {
+	var (
+		executed atomic.Int64
+		mux      = NewTaskMux(WithStreamResponseMap(&testPublisher{name: "test"}))
+		wg       sync.WaitGroup
+	)
+	_ = mux.Failover(func(s string) {})
+	mux.Handle("test", func(s string) string { executed.Add(1); return s }).Then(func(s string) {})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, mux.Receive(mustMessageFrom(WithPayload("test", "test"))))
+				assert.NoError(t, mux.Receive(mustMessageFrom(WithPayload(fmt.Sprintf("plugin%d", j%3), "test"))))
+				_ = mux.TaskMap()
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("plugin%d", i%3)
+		mux.Handle(name, func(s string) string { return s }).Then(func(s string) {})
+		assert.NoError(t, mux.Unhandle(name))
+		_ = mux.Failover(func(s string) {})
+	}
+	wg.Wait()
+	assert.Equal(t, int64(400), executed.Load())
+	assert.Equal(t, map[string][]string{"test": {"test.1"}, "test.1": {}}, mux.TaskMap())
+}
+
+func TestMuxAnnounceTasks(t *testing.T) {
+	var (
+		store   = &testAppRegistry{}
+		cluster = NewCluster("app", ClusterWithStores(store))
+		mux     = NewTaskMux(WithClusterObject(cluster))
+	)
+	mux.Handle("a", func(s string) {})
+	assert.Nil(t, store.lastTasks(), "changes before the init are not announced")
+	assert.NoError(t, mux.FinishInit())
+	assert.Equal(t, map[string][]string{"a": {}}, store.lastTasks())
+
+	mux.Handle("a>b", func(s string) {})
+	assert.Equal(t, map[string][]string{"a": {"b"}, "b": {}}, store.lastTasks())
+	assert.NoError(t, mux.Unhandle("b"))
+	assert.Equal(t, map[string][]string{"a": {}}, store.lastTasks())
+	assert.NoError(t, mux.Close())
+}
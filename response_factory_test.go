@@ -17,6 +17,22 @@ func (p *testPublisher) PublishAndReturnIDs(ctx context.Context, messages ...any
 	return nil, nil
 }
 
+type testRecordPublisher struct{ events []Event }
+
+func (p *testRecordPublisher) Publish(ctx context.Context, messages ...any) error {
+	for _, msg := range messages {
+		p.events = append(p.events, msg.(Event))
+	}
+	return nil
+}
+
+type testMessagePublisher struct{ messages []any }
+
+func (p *testMessagePublisher) Publish(ctx context.Context, messages ...any) error {
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
 func TestMultistreamResponseFactory(t *testing.T) {
 	fc := NewMultistreamResponseFactory(
 		"item1", "item2", &testPublisher{name: "test1"},
@@ -0,0 +1,108 @@
+package asyncp
+
+import (
+	"reflect"
+	"sync"
+)
+
+// responseEmitterFnk writes every element of the stream value as separate response
+type responseEmitterFnk func(v reflect.Value, responseWriter ResponseWriter) error
+
+// responseEmitters cache of emitters by the result type
+var responseEmitters sync.Map
+
+// responseEmitterOf returns cached emitter for the type or nil if the type is not a stream
+func responseEmitterOf(t reflect.Type) responseEmitterFnk {
+	if emit, ok := responseEmitters.Load(t); ok {
+		return emit.(responseEmitterFnk)
+	}
+	emit := newResponseEmitter(t)
+	responseEmitters.Store(t, emit)
+	return emit
+}
+
+// newResponseEmitter returns emitter for the types []T, <-chan T, iter.Seq[T]
+// and iter.Seq2[T, error] or nil for any other type.
+// Byte slices are considered as the raw payload and never splitted.
+func newResponseEmitter(t reflect.Type) responseEmitterFnk {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		return emitSlice
+	case reflect.Chan:
+		if t.ChanDir()&reflect.RecvDir == 0 {
+			return nil
+		}
+		return emitChan
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			return nil
+		}
+		yield := t.In(0)
+		if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+			return nil
+		}
+		switch {
+		case yield.NumIn() == 1:
+		case yield.NumIn() == 2 && yield.In(1) == errorType:
+		default:
+			return nil
+		}
+		return func(v reflect.Value, responseWriter ResponseWriter) error {
+			return emitSeq(yield, v, responseWriter)
+		}
+	}
+	return nil
+}
+
+func emitSlice(v reflect.Value, responseWriter ResponseWriter) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := emitValue(v.Index(i), responseWriter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func emitChan(v reflect.Value, responseWriter ResponseWriter) error {
+	for {
+		item, ok := v.Recv()
+		if !ok {
+			return nil
+		}
+		if err := emitValue(item, responseWriter); err != nil {
+			return err
+		}
+	}
+}
+
+func emitSeq(yieldType reflect.Type, v reflect.Value, responseWriter ResponseWriter) (err error) {
+	var (
+		resContinue = []reflect.Value{reflect.ValueOf(true)}
+		resBreak    = []reflect.Value{reflect.ValueOf(false)}
+	)
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		if len(args) > 1 && !isNilValue(args[1]) {
+			err = args[1].Interface().(error)
+			return resBreak
+		}
+		if err = emitValue(args[0], responseWriter); err != nil {
+			return resBreak
+		}
+		return resContinue
+	})
+	v.Call([]reflect.Value{yield})
+	return err
+}
+
+func emitValue(v reflect.Value, responseWriter ResponseWriter) error {
+	if isNilValue(v) {
+		return nil
+	}
+	return responseWriter.WriteResonse(v.Interface())
+}
@@ -0,0 +1,62 @@
+package asyncp
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseStream(t *testing.T) {
+	var (
+		res []int
+		mux = NewTaskMux(WithResponseFactory(NewProxyResponseFactory()))
+	)
+	collect := func(_ context.Context, v int) (any, error) {
+		res = append(res, v)
+		return nil, nil
+	}
+	Then(Handle(mux, "slice", func(_ context.Context, n int) ([]int, error) {
+		return []int{n, n + 1}, nil
+	}), collect)
+	Then(Handle(mux, "chan", func(_ context.Context, n int) (<-chan int, error) {
+		ch := make(chan int, 2)
+		ch <- n
+		ch <- n + 1
+		close(ch)
+		return ch, nil
+	}), collect)
+	Then(Handle(mux, "seq", func(_ context.Context, n int) (iter.Seq[int], error) {
+		return slices.Values([]int{n, n + 1}), nil
+	}), collect)
+	mux.Handle("seq2", func(n int) iter.Seq2[int, error] {
+		return func(yield func(int, error) bool) {
+			_ = yield(n, nil) && yield(0, fmt.Errorf("seq error"))
+		}
+	}).Then(collect)
+	mux.Handle("single", ExtFuncTask(func(n int) []int {
+		return []int{n, n}
+	}, WithSingleResponse())).Then(func(v []int) {
+		res = append(res, len(v))
+	})
+	mux.Handle("typed", TypedFuncTask[int, []int](func(_ context.Context, n int) ([]int, error) {
+		return []int{n, n + 1}, nil
+	})).Then(collect)
+	mux.Handle("typedSingle", NewTypedTask(func(_ context.Context, n int) ([]int, error) {
+		return []int{n, n}, nil
+	}, WithSingleResponse())).Then(func(v []int) {
+		res = append(res, len(v))
+	})
+
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("slice", 1)))
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("chan", 3)))
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("seq", 5)))
+	assert.EqualError(t, mux.ExecuteEvent(WithPayload("seq2", 7)), "seq error")
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("single", 9)))
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("typed", 11)))
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("typedSingle", 13)))
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 2, 11, 12, 2}, res)
+}
@@ -2,8 +2,11 @@ package asyncp
 
 import (
 	"context"
+	"sync"
 
 	"go.uber.org/multierr"
+
+	"github.com/demdxx/asyncp/v2/journal"
 )
 
 type responseWriterRelseasePool interface {
@@ -41,6 +44,50 @@ func (f ResponseHandlerFnk) Release() error {
 	return nil
 }
 
+// responseEvent returns the new event with the response value after the parent event.
+// The event is taken from the pool if it's defined and the value is not an event.
+func responseEvent(mux *TaskMux, prom Promise, parent Event, name string, value any, repeat bool, pool *sync.Pool) (_ Event, pooled bool) {
+	var (
+		ev       Event
+		withName = name != "" || !repeat
+	)
+	switch v := value.(type) {
+	case Event:
+		ev = v
+		if withName {
+			ev = ev.WithName(name)
+		}
+	default:
+		payload := mux.responsePayload(prom, value)
+		if p, ok := parent.(*event); ok {
+			var newEvent *event
+			if pooled = pool != nil; pooled {
+				newEvent = acquireEvent(pool)
+			} else {
+				newEvent = &event{}
+			}
+			p.copyTo(newEvent)
+			newEvent.setPayload(payload)
+			if withName {
+				newEvent.name = name
+			}
+			ev = newEvent
+		} else {
+			ev = parent.WithPayload(payload)
+			if withName {
+				ev = ev.WithName(name)
+			}
+		}
+	}
+	if repeat {
+		ev = ev.Repeat(parent)
+	} else {
+		ev = ev.After(parent)
+	}
+	ev.SetMux(mux)
+	return ev, pooled
+}
+
 type responseProxyWriter struct {
 	event   Event
 	promise Promise
@@ -53,6 +100,10 @@ func (wr *responseProxyWriter) WriteResonse(value any) error {
 		err    error
 		events = wr.promise.TargetEventName()
 	)
+	if len(events) == 0 && wr.event.CorrelationID() != "" {
+		// The end of the chain returns the result to the requester
+		return wr.mux.reply(wr.mux.newExecContext(), wr.promise, wr.event, value, nil)
+	}
 	for _, eventName := range events {
 		err = multierr.Append(err, wr.writeResonseWithEventName(eventName, value, false))
 	}
@@ -67,22 +118,20 @@ func (wr *responseProxyWriter) RepeatWithResponse(value any) error {
 }
 
 func (wr *responseProxyWriter) writeResonseWithEventName(name string, value any, repeat bool) error {
-	var ev Event
-	switch v := value.(type) {
-	case Event:
-		ev = v
-	default:
-		ev = wr.event.WithPayload(value)
+	// Response events are processed in place, so they can be reused
+	ev, pooled := responseEvent(wr.mux, wr.promise, wr.event, name, value, repeat, wr.mux.eventPool())
+	if pooled {
+		defer ReleaseEvent(ev)
 	}
-	if name != "" || !repeat {
-		ev = ev.WithName(name)
+	if err := wr.mux.validateOutput(wr.promise, ev); err != nil {
+		return err
 	}
-	if repeat {
-		ev = ev.Repeat(wr.event)
+	if name != "" || repeat {
+		wr.mux.trackSpawn(ev)
 	} else {
-		ev = ev.After(wr.event)
+		wr.mux.trackResult(ev)
 	}
-	ev.SetMux(wr.mux)
+	wr.mux.journalEvent(journal.DirectionOut, ev)
 	return wr.mux.ExecuteEvent(ev)
 }
 
@@ -107,6 +156,10 @@ func (wr *responseStreamWriter) WriteResonse(value any) error {
 		err    error
 		events = wr.promise.TargetEventName()
 	)
+	if len(events) == 0 && wr.event.CorrelationID() != "" {
+		// The end of the chain returns the result to the requester
+		return wr.mux.reply(wr.getExecContext(), wr.promise, wr.event, value, wr.wstream)
+	}
 	for _, eventName := range events {
 		err = multierr.Append(err, wr.writeResonseWithEventName(eventName, value, false))
 	}
@@ -121,23 +174,18 @@ func (wr *responseStreamWriter) RepeatWithResponse(value any) error {
 }
 
 func (wr *responseStreamWriter) writeResonseWithEventName(name string, value any, repeat bool) error {
-	var ev Event
-	switch v := value.(type) {
-	case Event:
-		ev = v
-	default:
-		ev = wr.event.WithPayload(value)
+	// Publishers can keep the event, so it's never reused
+	ev, _ := responseEvent(wr.mux, wr.promise, wr.event, name, value, repeat, nil)
+	if err := wr.mux.validateOutput(wr.promise, ev); err != nil {
+		return err
 	}
-	if name != "" || !repeat {
-		ev = ev.WithName(name)
-	}
-	if repeat {
-		ev = ev.Repeat(wr.event)
+	if name != "" || repeat {
+		wr.mux.trackSpawn(ev)
 	} else {
-		ev = ev.After(wr.event)
+		wr.mux.trackResult(ev)
 	}
-	ev.SetMux(wr.mux)
-	return wr.wstream.Publish(wr.getExecContext(), ev)
+	wr.mux.journalEvent(journal.DirectionOut, ev)
+	return publishEvent(wr.getExecContext(), wr.wstream, ev)
 }
 
 func (wr *responseStreamWriter) Release() error {
@@ -153,3 +201,11 @@ func (wr *responseStreamWriter) getExecContext() context.Context {
 	}
 	return context.Background()
 }
+
+// keepResponseWriter prevents release of the writer by the wrapped task,
+// the writer is released by the owner after the execution
+type keepResponseWriter struct {
+	ResponseWriter
+}
+
+func (keepResponseWriter) Release() error { return nil }
@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Compare producer and consumer schemas and returns the list of problems
+// when the producer can emit payloads which are not accepted by the consumer.
+// The check is structural: types, required and additional properties, items and enums.
+// Null values are not compared as Go decoders accept them for any type.
+func Compare(producer, consumer []byte) ([]string, error) {
+	var prodDoc, consDoc any
+	if err := json.Unmarshal(producer, &prodDoc); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(consumer, &consDoc); err != nil {
+		return nil, err
+	}
+	var problems []string
+	compareSchemas("", prodDoc, consDoc, &problems)
+	return problems, nil
+}
+
+func compareSchemas(path string, producer, consumer any, problems *[]string) {
+	addProblem := func(format string, args ...any) {
+		*problems = append(*problems, "/"+path+": "+fmt.Sprintf(format, args...))
+	}
+	switch c := consumer.(type) {
+	case nil:
+		return
+	case bool:
+		if !c {
+			addProblem("consumer rejects any value")
+		}
+		return
+	}
+	cons, _ := consumer.(map[string]any)
+	prod, _ := producer.(map[string]any)
+	if len(cons) == 0 || prod == nil {
+		// Producer without the schema could emit anything
+		return
+	}
+
+	// Types of the producer must be accepted by the consumer
+	if consTypes := schemaTypes(cons); len(consTypes) > 0 {
+		prodTypes := schemaTypes(prod)
+		if len(prodTypes) == 0 {
+			addProblem("type is undefined, expected %v", consTypes)
+		}
+		for _, tp := range prodTypes {
+			if !slices.Contains(consTypes, tp) && (tp != "integer" || !slices.Contains(consTypes, "number")) {
+				addProblem("type %q is not accepted, expected %v", tp, consTypes)
+			}
+		}
+	}
+
+	// Properties required by the consumer must be always present
+	prodRequired := schemaStrings(prod["required"])
+	for _, name := range schemaStrings(cons["required"]) {
+		if !slices.Contains(prodRequired, name) {
+			addProblem("required property %q is optional or missing", name)
+		}
+	}
+
+	var (
+		consProps, _ = cons["properties"].(map[string]any)
+		prodProps, _ = prod["properties"].(map[string]any)
+	)
+	for _, name := range sortedKeys(consProps) {
+		if prodProp, ok := prodProps[name]; ok {
+			compareSchemas(joinPath(path, name), prodProp, consProps[name], problems)
+		}
+	}
+	if additional, ok := cons["additionalProperties"]; ok {
+		for _, name := range sortedKeys(prodProps) {
+			if _, ok := consProps[name]; !ok {
+				compareSchemas(joinPath(path, name), prodProps[name], additional, problems)
+			}
+		}
+	}
+
+	if consItems, ok := cons["items"]; ok {
+		if prodItems, ok := prod["items"]; ok {
+			compareSchemas(joinPath(path, "items"), prodItems, consItems, problems)
+		}
+	}
+
+	// Values of the producer enum must be accepted by the consumer
+	if consEnum, ok := cons["enum"].([]any); ok {
+		prodEnum, _ := prod["enum"].([]any)
+		for _, val := range prodEnum {
+			if !slices.ContainsFunc(consEnum, func(v any) bool { return fmt.Sprint(v) == fmt.Sprint(val) }) {
+				addProblem("enum value %v is not accepted", val)
+			}
+		}
+	}
+}
+
+func schemaTypes(sch map[string]any) []string {
+	var types []string
+	switch tp := sch["type"].(type) {
+	case string:
+		types = []string{tp}
+	case []any:
+		types = schemaStrings(tp)
+	}
+	return slices.DeleteFunc(types, func(s string) bool { return s == "null" })
+}
+
+func schemaStrings(val any) []string {
+	list, _ := val.([]any)
+	strs := make([]string, 0, len(list))
+	for _, it := range list {
+		if s, ok := it.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func sortedKeys(mp map[string]any) []string {
+	keys := make([]string, 0, len(mp))
+	for key := range mp {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "/" + name
+}
@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// typeSchema returns JSON Schema document of the type.
+// Recursive types and types with custom marshaling accept any value.
+func typeSchema(t reflect.Type, visited map[reflect.Type]bool) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+	if t.Kind() == reflect.Pointer {
+		sch := typeSchema(t.Elem(), visited)
+		if tp, ok := sch["type"].(string); ok {
+			sch["type"] = []string{tp, "null"}
+		}
+		return sch
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType, t.Implements(jsonMarshalerType), reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]any{}
+	case t.Implements(textMarshalerType), reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		sch := map[string]any{"items": typeSchema(t.Elem(), visited)}
+		if t.Kind() == reflect.Slice {
+			sch["type"] = []string{"array", "null"}
+		} else {
+			sch["type"] = "array"
+			sch["minItems"] = t.Len()
+			sch["maxItems"] = t.Len()
+		}
+		return sch
+	case reflect.Map:
+		return map[string]any{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": typeSchema(t.Elem(), visited),
+		}
+	case reflect.Struct:
+		if visited[t] {
+			return map[string]any{}
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		var (
+			props    = map[string]any{}
+			required []string
+		)
+		structFields(t, visited, props, &required)
+		sch := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			sch["required"] = required
+		}
+		return sch
+	}
+	return map[string]any{}
+}
+
+func structFields(t reflect.Type, visited map[reflect.Type]bool, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				structFields(fieldType, visited, props, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = typeSchema(field.Type, visited)
+		if slices.Contains(strings.Split(field.Tag.Get("jsonschema"), ","), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package schema provides JSON Schema validation of the event payloads.
+// Schema can be declared explicitly or derived from the Go type.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/demdxx/asyncp/v2/libs/errors"
+)
+
+var schemaCounter atomic.Uint64
+
+// ValidationError contains the list of payload mismatches with the schema
+type ValidationError struct {
+	Errors []string `json:"errors"`
+}
+
+// Error message of validation
+func (e *ValidationError) Error() string {
+	return errors.ErrValidation.Error() + ": " + strings.Join(e.Errors, "; ")
+}
+
+// Is checks compatibility with the ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == errors.ErrValidation
+}
+
+// Schema of the payload
+type Schema struct {
+	source   json.RawMessage
+	compiled *jsonschema.Schema
+}
+
+// Parse JSON Schema document
+func Parse(data []byte) (*Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		url      = fmt.Sprintf("mem://schema/%d.json", schemaCounter.Add(1))
+		compiler = jsonschema.NewCompiler()
+	)
+	if err = compiler.AddResource(url, doc); err != nil {
+		return nil, err
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{source: append(json.RawMessage(nil), data...), compiled: compiled}, nil
+}
+
+// MustParse JSON Schema document or panic
+func MustParse(data []byte) *Schema {
+	sch, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return sch
+}
+
+// For returns schema derived from the Go type
+func For[T any]() *Schema {
+	return FromType(reflect.TypeFor[T]())
+}
+
+// FromType returns schema derived from the Go type.
+// Only fields with the `jsonschema:"required"` tag are required.
+func FromType(t reflect.Type) *Schema {
+	data, err := json.Marshal(typeSchema(t, map[reflect.Type]bool{}))
+	if err != nil {
+		panic(err)
+	}
+	return MustParse(data)
+}
+
+// Validate JSON encoded data
+func (s *Schema) Validate(data []byte) error {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return &ValidationError{Errors: []string{err.Error()}}
+	}
+	return s.validate(inst)
+}
+
+// ValidateValue validates the value in the JSON representation
+func (s *Schema) ValidateValue(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.Validate(data)
+}
+
+// MarshalJSON returns source of the schema
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return s.source, nil
+}
+
+// UnmarshalJSON parses the schema
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	sch, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	*s = *sch
+	return nil
+}
+
+// String returns source of the schema
+func (s *Schema) String() string {
+	return string(s.source)
+}
+
+func (s *Schema) validate(inst any) error {
+	err := s.compiled.Validate(inst)
+	if err == nil {
+		return nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return &ValidationError{Errors: []string{err.Error()}}
+	}
+	var (
+		output = verr.BasicOutput()
+		errs   = make([]string, 0, len(output.Errors))
+	)
+	for _, unit := range output.Errors {
+		if unit.Error != nil {
+			errs = append(errs, "/"+strings.TrimPrefix(unit.InstanceLocation, "/")+": "+unit.Error.String())
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, verr.Error())
+	}
+	return &ValidationError{Errors: errs}
+}
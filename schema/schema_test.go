@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2/libs/errors"
+)
+
+func TestSchemaFromType(t *testing.T) {
+	type item struct {
+		Title     string    `json:"title" jsonschema:"required"`
+		Count     uint      `json:"count,omitempty"`
+		Tags      []string  `json:"tags,omitempty"`
+		Next      *item     `json:"next,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	sch := For[item]()
+	assert.NoError(t, sch.Validate([]byte(`{"title":"test","created_at":"2024-01-01T00:00:00Z","next":{"title":"next","created_at":"2024-01-01T00:00:00Z"}}`)))
+	assert.NoError(t, sch.ValidateValue(item{Title: "test"}))
+	assert.NoError(t, sch.Validate([]byte(`{"title":"test"}`)))
+	assert.ErrorIs(t, sch.Validate([]byte(`{"count":1}`)), errors.ErrValidation)
+
+	err := sch.Validate([]byte(`{"title":1,"count":-1}`))
+	assert.ErrorIs(t, err, errors.ErrValidation)
+	if verr, ok := err.(*ValidationError); assert.True(t, ok) {
+		assert.GreaterOrEqual(t, len(verr.Errors), 2)
+	}
+}
+
+func TestSchemaParse(t *testing.T) {
+	sch, err := Parse([]byte(`{"type":"object","required":["id"],"properties":{"id":{"type":"integer"}}}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, sch.Validate([]byte(`{"id":1}`)))
+	assert.ErrorIs(t, sch.Validate([]byte(`{}`)), errors.ErrValidation)
+	assert.ErrorIs(t, sch.Validate([]byte(`{`)), errors.ErrValidation)
+
+	data, err := json.Marshal(sch)
+	assert.NoError(t, err)
+	var newSch Schema
+	assert.NoError(t, json.Unmarshal(data, &newSch))
+	assert.NoError(t, newSch.Validate([]byte(`{"id":1}`)))
+
+	_, err = Parse([]byte(`{"type":1}`))
+	assert.Error(t, err)
+}
+
+func TestSchemaCompare(t *testing.T) {
+	type producer struct {
+		ID    int    `json:"id" jsonschema:"required"`
+		Title string `json:"title,omitempty"`
+		Kind  string `json:"kind" jsonschema:"required"`
+	}
+	type consumer struct {
+		ID    float64 `json:"id" jsonschema:"required"`
+		Title string  `json:"title" jsonschema:"required"`
+		Kind  int     `json:"kind,omitempty"`
+	}
+	prod, _ := json.Marshal(For[producer]())
+	cons, _ := json.Marshal(For[consumer]())
+
+	problems, err := Compare(prod, prod)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	problems, err = Compare(prod, cons)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		`/: required property "title" is optional or missing`,
+		`/kind: type "string" is not accepted, expected [integer]`,
+	}, problems)
+
+	_, err = Compare([]byte(`{`), cons)
+	assert.Error(t, err)
+}
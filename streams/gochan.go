@@ -12,4 +12,6 @@ func init() {
 	proxy := gochan.New(max(runtime.NumCPU(), 1))
 	subscribers["gochan"] = func(ctx context.Context, url string) (nc.Subscriber, error) { return proxy, nil }
 	subscribers["chan"] = subscribers["gochan"]
+	publishers["gochan"] = func(ctx context.Context, url string) (nc.Publisher, error) { return proxy.Publisher(), nil }
+	publishers["chan"] = publishers["gochan"]
 }
@@ -3,12 +3,18 @@ package streams
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 
 	nc "github.com/geniusrabbit/notificationcenter/v2"
+
+	"github.com/demdxx/asyncp/v2"
 )
 
-var subscribers = map[string]func(context.Context, string) (nc.Subscriber, error){}
+var (
+	subscribers = map[string]func(context.Context, string) (nc.Subscriber, error){}
+	publishers  = map[string]func(context.Context, string) (nc.Publisher, error){}
+)
 
 // SubscriberFromURL establish new connection to the specific type of stream
 func SubscriberFromURL(ctx context.Context, connURL string) (nc.Subscriber, error) {
@@ -22,3 +28,49 @@ func SubscriberFromURL(ctx context.Context, connURL string) (nc.Subscriber, erro
 	}
 	return f(ctx, connURL)
 }
+
+// PublisherFromURL establish new publisher connection to the specific type of stream.
+//
+// Events are encoded as CloudEvents if the `cloudevents` param is defined:
+//
+//	kafka://host:9092/topic?cloudevents=structured&cloudevents_source=/my/app
+//
+// The binary mode is accepted only for publishers with the message headers support.
+func PublisherFromURL(ctx context.Context, connURL string) (nc.Publisher, error) {
+	u, err := url.Parse(connURL)
+	if err != nil {
+		return nil, err
+	}
+	f := publishers[u.Scheme]
+	if f == nil {
+		return nil, fmt.Errorf("unsupported publisher %s", u.Scheme)
+	}
+	query := u.Query()
+	ceMode, ceSource := query.Get("cloudevents"), query.Get("cloudevents_source")
+	query.Del("cloudevents")
+	query.Del("cloudevents_source")
+	u.RawQuery = query.Encode()
+
+	pub, err := f(ctx, u.String())
+	if err != nil || ceMode == "" {
+		return pub, err
+	}
+	opts := asyncp.CloudEventsOptions{Source: ceSource}
+	switch ceMode {
+	case "structured", "1", "true":
+	case "binary":
+		if _, ok := pub.(asyncp.HeaderPublisher); !ok {
+			if closer, ok := pub.(io.Closer); ok {
+				_ = closer.Close()
+			}
+			return nil, fmt.Errorf("cloudevents binary mode is not supported by %s publisher", u.Scheme)
+		}
+		opts.Mode = asyncp.CloudEventsBinary
+		if u.Scheme == "kafka" {
+			opts.HeaderPrefix = asyncp.CloudEventsKafkaPrefix
+		}
+	default:
+		return nil, fmt.Errorf("unsupported cloudevents mode %s", ceMode)
+	}
+	return asyncp.PublisherWithCloudEvents(pub, opts), nil
+}
@@ -0,0 +1,21 @@
+package streams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublisherFromURLCloudEvents(t *testing.T) {
+	ctx := context.Background()
+	pub, err := PublisherFromURL(ctx, "gochan://local?cloudevents=structured&cloudevents_source=/test")
+	assert.NoError(t, err)
+	assert.NotNil(t, pub)
+
+	_, err = PublisherFromURL(ctx, "gochan://local?cloudevents=binary")
+	assert.EqualError(t, err, "cloudevents binary mode is not supported by gochan publisher")
+
+	_, err = PublisherFromURL(ctx, "gochan://local?cloudevents=unknown")
+	assert.Error(t, err)
+}
@@ -13,4 +13,7 @@ func init() {
 	subscribers["kafka"] = func(_ context.Context, conn string) (nc.Subscriber, error) {
 		return kafka.NewSubscriber(kafka.WithKafkaURL(conn))
 	}
+	publishers["kafka"] = func(ctx context.Context, conn string) (nc.Publisher, error) {
+		return kafka.NewPublisher(ctx, kafka.WithKafkaURL(conn))
+	}
 }
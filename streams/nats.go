@@ -13,4 +13,7 @@ func init() {
 	subscribers["nats"] = func(_ context.Context, conn string) (nc.Subscriber, error) {
 		return nats.NewSubscriber(nats.WithNatsURL(conn))
 	}
+	publishers["nats"] = func(_ context.Context, conn string) (nc.Publisher, error) {
+		return nats.NewPublisher(nats.WithNatsURL(conn))
+	}
 }
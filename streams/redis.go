@@ -13,4 +13,7 @@ func init() {
 	subscribers["redis"] = func(_ context.Context, connURL string) (nc.Subscriber, error) {
 		return redis.NewSubscriber(redis.WithRedisURL(connURL))
 	}
+	publishers["redis"] = func(_ context.Context, connURL string) (nc.Publisher, error) {
+		return redis.NewPublisher(redis.WithRedisURL(connURL))
+	}
 }
@@ -8,7 +8,8 @@ import (
 	nc "github.com/geniusrabbit/notificationcenter/v2"
 )
 
-// ListenAndServe task service for sources
+// ListenAndServe task service for sources.
+// Listening is stopped when the mux starts draining.
 func ListenAndServe(ctx context.Context, srv *asyncp.TaskMux, sources ...any) error {
 	subscribers := make([]nc.Subscriber, 0, len(sources))
 	for _, src := range sources {
@@ -32,6 +33,18 @@ func ListenAndServe(ctx context.Context, srv *asyncp.TaskMux, sources ...any) er
 	if err := subs.Subscribe(ctx, srv); err != nil {
 		return err
 	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-srv.Drained():
+			cancel()
+		}
+	}()
 	e := subs.Listen(ctx)
+	if ctx.Err() != nil && srv.IsDraining() {
+		return nil
+	}
 	return e
 }
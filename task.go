@@ -35,14 +35,14 @@ func (f FuncTask) Async(options ...AsyncOption) *AsyncTask {
 }
 
 // TaskFrom converts income handler type to Task interface
-func TaskFrom(handler any) Task {
+func TaskFrom(handler any, options ...TaskOption) Task {
 	switch h := handler.(type) {
 	case Task:
 		return h
 	case func(ctx context.Context, event Event, responseWriter ResponseWriter) error:
 		return FuncTask(h)
 	default:
-		return ExtFuncTask(h)
+		return ExtFuncTask(h, options...)
 	}
 }
 
@@ -53,13 +53,16 @@ var (
 	responseWriterType = reflect.TypeOf((*ResponseWriter)(nil)).Elem()
 )
 
-// ExtFuncTask wraps function argument with arbitrary input data type
-func ExtFuncTask(f any) FuncTask {
+// ExtFuncTask wraps function argument with arbitrary input data type.
+// Results of types []T, <-chan T, iter.Seq[T] and iter.Seq2[T, error]
+// are written as separate event for every element if WithSingleResponse is not defined.
+func ExtFuncTask(f any, options ...TaskOption) FuncTask {
 	fv := reflect.ValueOf(f)
 	if fv.Kind() != reflect.Func {
 		panic("argument must be a function")
 	}
 	var (
+		opts      = newTaskOptions(options...)
 		ft        = fv.Type()
 		argMapper = make([]func(context.Context, Event, ResponseWriter) (reflect.Value, error), 0, ft.NumIn())
 		retMapper = make([]func(reflect.Value, ResponseWriter) error, 0, ft.NumOut())
@@ -83,6 +86,9 @@ func ExtFuncTask(f any) FuncTask {
 			argMapper = append(argMapper, func(_ context.Context, event Event, _ ResponseWriter) (reflect.Value, error) {
 				newValue, newValueI := newValue(inType)
 				err := event.Payload().Decode(newValueI)
+				if inType.Kind() != reflect.Ptr {
+					return newValue.Elem(), err
+				}
 				return newValue, err
 			})
 		}
@@ -92,16 +98,23 @@ func ExtFuncTask(f any) FuncTask {
 		switch outType {
 		case errorType:
 			retMapper = append(retMapper, func(v reflect.Value, _ ResponseWriter) error {
-				if v.IsNil() {
+				if isNilValue(v) {
 					return nil
 				}
 				return v.Interface().(error)
 			})
 		default:
+			var emit responseEmitterFnk
+			if !opts.SingleResponse {
+				emit = responseEmitterOf(outType)
+			}
 			retMapper = append(retMapper, func(v reflect.Value, responseWriter ResponseWriter) error {
-				if v.IsNil() {
+				if isNilValue(v) {
 					return nil
 				}
+				if emit != nil {
+					return emit(v, responseWriter)
+				}
 				return responseWriter.WriteResonse(v.Interface())
 			})
 		}
@@ -0,0 +1,129 @@
+package asyncp
+
+import (
+	"time"
+
+	"github.com/demdxx/asyncp/v2/schema"
+)
+
+// TaskOption of the single task configuration
+type TaskOption func(opt *TaskOptions)
+
+// TaskOptions of the task execution and response processing
+type TaskOptions struct {
+	// SingleResponse disables splitting of the slice, channel and iterator
+	// results into the separate events
+	SingleResponse bool
+
+	// Codec of the response payloads overrides the default codec of the mux
+	Codec Codec
+
+	// InputSchema of the event payload checked before the task execution
+	InputSchema *Schema
+
+	// OutputSchema of the response payloads checked before publishing
+	OutputSchema *Schema
+
+	// PayloadVersion of the response payloads
+	PayloadVersion int
+
+	// Retry count of the failed task execution
+	Retry int
+
+	// RetryBackoff before the second attempt, it's doubled for every next one
+	RetryBackoff time.Duration
+
+	// Timeout of the single task execution attempt
+	Timeout time.Duration
+
+	// inputFromType derives InputSchema from the Go type of the handler argument
+	inputFromType bool
+}
+
+func newTaskOptions(options ...TaskOption) TaskOptions {
+	var opts TaskOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+// prepare options for the handler
+func (opts *TaskOptions) prepare(handler any) error {
+	if opts.inputFromType && opts.InputSchema == nil {
+		payloadType := payloadTypeOf(handler)
+		if payloadType == nil {
+			return ErrUndefinedPayloadType
+		}
+		opts.InputSchema = schema.FromType(payloadType)
+	}
+	return nil
+}
+
+// WithCodec of the task response payloads
+func WithCodec(c Codec) TaskOption {
+	return func(opt *TaskOptions) {
+		opt.Codec = c
+	}
+}
+
+// WithSingleResponse writes slice, channel and iterator results as one payload
+// instead of the separate event for every element
+func WithSingleResponse() TaskOption {
+	return func(opt *TaskOptions) {
+		opt.SingleResponse = true
+	}
+}
+
+// WithInputSchema checks event payloads before the task execution.
+// Rejected events are routed to the validation error handler.
+// Received payloads of not JSON codecs are not validated.
+func WithInputSchema(sch *Schema) TaskOption {
+	return func(opt *TaskOptions) {
+		opt.InputSchema = sch
+	}
+}
+
+// WithInputValidation checks event payloads with the schema
+// derived from the Go type of the handler argument
+func WithInputValidation() TaskOption {
+	return func(opt *TaskOptions) {
+		opt.inputFromType = true
+	}
+}
+
+// WithOutputSchema checks response payloads before publishing
+func WithOutputSchema(sch *Schema) TaskOption {
+	return func(opt *TaskOptions) {
+		opt.OutputSchema = sch
+	}
+}
+
+// WithPayloadVersion marks response payloads of the task with the version
+func WithPayloadVersion(version int) TaskOption {
+	return func(opt *TaskOptions) {
+		opt.PayloadVersion = version
+	}
+}
+
+// WithRetry executes the failed task again up to the count times
+func WithRetry(count int) TaskOption {
+	return func(opt *TaskOptions) {
+		opt.Retry = count
+	}
+}
+
+// WithRetryBackoff of the failed task, the delay is doubled for every next attempt
+func WithRetryBackoff(backoff time.Duration) TaskOption {
+	return func(opt *TaskOptions) {
+		opt.RetryBackoff = backoff
+	}
+}
+
+// WithTimeout limits the execution time of the task attempt.
+// The handler should respect the context to be interrupted.
+func WithTimeout(timeout time.Duration) TaskOption {
+	return func(opt *TaskOptions) {
+		opt.Timeout = timeout
+	}
+}
@@ -0,0 +1,136 @@
+package asyncp
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// DefaultRetryBackoff before the second attempt of the failed task,
+// the delay is doubled for every next attempt
+const DefaultRetryBackoff = 100 * time.Millisecond
+
+// maxRetryBackoff limits the delay between attempts
+const maxRetryBackoff = time.Minute
+
+// retryTask executes the task again in case of error and limits
+// the execution time of every attempt
+type retryTask struct {
+	task    Task
+	retry   int
+	backoff time.Duration
+	timeout time.Duration
+}
+
+// wrapRetryTask returns the task with retry and timeout control if it's required
+func wrapRetryTask(task Task, opts *TaskOptions) Task {
+	if opts.Retry <= 0 && opts.Timeout <= 0 {
+		return task
+	}
+	if asyncTask, ok := task.(*AsyncTask); ok {
+		// Async task returns right after the scheduling,
+		// so the control is applied to the inner task
+		asyncTask.task = wrapRetryTask(asyncTask.task, opts)
+		return asyncTask
+	}
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	return &retryTask{task: task, retry: opts.Retry, backoff: backoff, timeout: opts.Timeout}
+}
+
+// Execute the task while it fails and attempts are not exceeded.
+// Responses of the attempt are written only after its success.
+func (t *retryTask) Execute(ctx context.Context, event Event, responseWriter ResponseWriter) (err error) {
+	defer func() {
+		if err := responseWriter.Release(); err != nil {
+			log.Printf("release response writer: %s", err.Error())
+		}
+	}()
+	var buf bufferResponseWriter
+	for attempt := 0; ; attempt++ {
+		buf.reset()
+		if err = t.execute(ctx, event, &buf); err == nil || errors.Is(err, ErrSkipEvent) {
+			if commitErr := buf.commit(responseWriter); commitErr != nil {
+				return commitErr
+			}
+			return err
+		}
+		if attempt >= t.retry || !t.wait(ctx, attempt) {
+			return err
+		}
+	}
+}
+
+func (t *retryTask) execute(ctx context.Context, event Event, responseWriter ResponseWriter) error {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	return t.task.Execute(ctx, event, responseWriter)
+}
+
+// wait the backoff delay of the attempt and returns false if the context is done
+func (t *retryTask) wait(ctx context.Context, attempt int) bool {
+	delay := maxRetryBackoff
+	if attempt < 16 {
+		delay = min(t.backoff<<attempt, maxRetryBackoff)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+type bufferedResponse struct {
+	value  any
+	repeat bool
+}
+
+// bufferResponseWriter keeps responses of the attempt until its success
+type bufferResponseWriter struct {
+	responses []bufferedResponse
+}
+
+// WriteResonse keeps the response in the buffer
+func (w *bufferResponseWriter) WriteResonse(response any) error {
+	w.responses = append(w.responses, bufferedResponse{value: response})
+	return nil
+}
+
+// RepeatWithResponse keeps the repeated response in the buffer
+func (w *bufferResponseWriter) RepeatWithResponse(response any) error {
+	w.responses = append(w.responses, bufferedResponse{value: response, repeat: true})
+	return nil
+}
+
+// Release is done by the owner of the buffer
+func (w *bufferResponseWriter) Release() error { return nil }
+
+// commit buffered responses into the writer in the same order
+func (w *bufferResponseWriter) commit(wr ResponseWriter) error {
+	for _, resp := range w.responses {
+		var err error
+		if resp.repeat {
+			err = wr.RepeatWithResponse(resp.value)
+		} else {
+			err = wr.WriteResonse(resp.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *bufferResponseWriter) reset() {
+	clear(w.responses)
+	w.responses = w.responses[:0]
+}
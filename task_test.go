@@ -2,7 +2,9 @@ package asyncp
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -71,3 +73,73 @@ func TestExtFuncTask(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "test2", res)
 }
+
+func TestExtFuncTaskValueArgs(t *testing.T) {
+	type item struct {
+		Text string `json:"text"`
+	}
+	var (
+		res = 0
+		mux = NewTaskMux(WithResponseFactory(NewProxyResponseFactory()))
+	)
+	mux.Handle("test", func(it item) (int, error) {
+		return len(it.Text), nil
+	}).Then(func(n int) error {
+		res = n
+		return nil
+	})
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("test", item{Text: "test"})))
+	assert.Equal(t, 4, res)
+}
+
+func TestTaskRetry(t *testing.T) {
+	var (
+		attempts int
+		result   int
+		mux      = NewTaskMux(WithResponseFactory(NewProxyResponseFactory()))
+	)
+	mux.Handle("retry", func(ctx context.Context, i int) (*int, error) {
+		if attempts++; attempts < 3 {
+			return nil, errors.New("temporary")
+		}
+		i++
+		return &i, nil
+	}, WithRetry(2), WithRetryBackoff(time.Millisecond)).Then(func(i int) error {
+		result += i
+		return nil
+	})
+	mux.Handle("fail", func(_ int) error {
+		attempts++
+		return errors.New("permanent")
+	}, WithRetry(1), WithRetryBackoff(time.Millisecond))
+	mux.Handle("timeout", func(ctx context.Context, _ int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(time.Millisecond))
+
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("retry", 1)))
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, result)
+
+	attempts = 0
+	assert.Error(t, mux.ExecuteEvent(WithPayload("fail", 1)))
+	assert.Equal(t, 2, attempts)
+
+	assert.ErrorIs(t, mux.ExecuteEvent(WithPayload("timeout", 1)), context.DeadlineExceeded)
+
+	// Responses of the failed attempts are not written
+	var responses []int
+	attempts = 0
+	mux.Handle("partial", FuncTask(func(ctx context.Context, event Event, responseWriter ResponseWriter) error {
+		attempts++
+		if err := responseWriter.WriteResonse(attempts); err != nil {
+			return err
+		}
+		if attempts < 2 {
+			return errors.New("temporary")
+		}
+		return nil
+	}), WithRetry(1), WithRetryBackoff(time.Millisecond)).Then(func(i int) { responses = append(responses, i) })
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("partial", 1)))
+	assert.Equal(t, []int{2}, responses)
+}
@@ -0,0 +1,135 @@
+package asyncp
+
+import (
+	"context"
+	"log"
+	"reflect"
+)
+
+// TypedFuncTask provides implementation of Task interface for the typed function.
+// Input payload is decoded into the `In` type and the `Out` value is written as response.
+// Returned nil values are not written into the response stream.
+// Results of types []T, <-chan T, iter.Seq[T] and iter.Seq2[T, error]
+// are written as separate event for every element with the default task options,
+// use NewTypedTask with WithSingleResponse option to write them as one payload.
+type TypedFuncTask[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Execute the typed function with decoded event payload
+func (f TypedFuncTask[In, Out]) Execute(ctx context.Context, event Event, responseWriter ResponseWriter) error {
+	return executeTyped(ctx, f, newTypedResult[Out](&TaskOptions{}), event, responseWriter)
+}
+
+// Async transforms task to the asynchronous executor
+func (f TypedFuncTask[In, Out]) Async(options ...AsyncOption) *AsyncTask {
+	return WrapAsyncTask(f, options...)
+}
+
+// typedTask wraps typed function with custom task options
+type typedTask[In, Out any] struct {
+	handler TypedFuncTask[In, Out]
+	options TaskOptions
+	result  typedResult[Out]
+}
+
+// NewTypedTask returns typed task with custom options
+func NewTypedTask[In, Out any](handler func(ctx context.Context, in In) (Out, error), options ...TaskOption) Task {
+	task := &typedTask[In, Out]{
+		handler: handler,
+		options: newTaskOptions(options...),
+	}
+	task.result = newTypedResult[Out](&task.options)
+	return task
+}
+
+// typedResult writes the Out results to the response, it's prepared once for the task
+type typedResult[Out any] struct {
+	// isNil checks results which are not written, it's nil for types which can't be nil
+	isNil func(out Out) bool
+
+	// emit writes every element of the stream result as separate response
+	emit responseEmitterFnk
+}
+
+func newTypedResult[Out any](opts *TaskOptions) typedResult[Out] {
+	var res typedResult[Out]
+	t := reflect.TypeFor[Out]()
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		res.isNil = func(out Out) bool {
+			var zero Out
+			return any(out) == any(zero)
+		}
+	case reflect.Interface, reflect.Map, reflect.Slice, reflect.Func:
+		// Values are not comparable or can keep the nil pointer
+		res.isNil = func(out Out) bool { return isNilValue(reflect.ValueOf(out)) }
+	}
+	if !opts.SingleResponse {
+		res.emit = responseEmitterOf(t)
+	}
+	return res
+}
+
+// Execute the typed function with decoded event payload
+func (t *typedTask[In, Out]) Execute(ctx context.Context, event Event, responseWriter ResponseWriter) error {
+	return executeTyped(ctx, t.handler, t.result, event, responseWriter)
+}
+
+func executeTyped[In, Out any](ctx context.Context, f TypedFuncTask[In, Out], result typedResult[Out], event Event, responseWriter ResponseWriter) error {
+	defer func() {
+		err := responseWriter.Release()
+		if err != nil {
+			log.Printf("release response writer: %s", err.Error())
+		}
+	}()
+	in, err := DecodePayload[In](event.Payload())
+	if err != nil {
+		return err
+	}
+	out, err := f(ctx, in)
+	if err != nil {
+		return err
+	}
+	if result.isNil != nil && result.isNil(out) {
+		return nil
+	}
+	if result.emit != nil {
+		return result.emit(reflect.ValueOf(out), responseWriter)
+	}
+	return responseWriter.WriteResonse(out)
+}
+
+// Handle register new typed task for specific chanel of the mux.
+// Task after other task can be defined by "parentTaskName>currentTaskName"
+//
+// Example:
+//
+//	asyncp.Handle(mux, "rss", func(ctx context.Context, link string) (*rssChannel, error) {...})
+func Handle[In, Out any](mux *TaskMux, taskName string, handler func(ctx context.Context, in In) (Out, error), options ...TaskOption) Promise {
+	return mux.Handle(taskName, NewTypedTask(handler, options...), options...)
+}
+
+// Then register new typed task which will be executed after the promise
+//
+// Example:
+//
+//	asyncp.Then(promise, func(ctx context.Context, channel *rssChannel) ([]rssItem, error) {...})
+func Then[In, Out any](prom Promise, handler func(ctx context.Context, in In) (Out, error), options ...TaskOption) Promise {
+	task := NewTypedTask(handler, options...)
+	if p, ok := prom.(*promise); ok {
+		return p.then(task, options...)
+	}
+	return prom.Then(task)
+}
+
+// ThenWithOptions register new task with options which will be executed after the promise.
+// Options are applied to the promises of the mux only.
+//
+// Example:
+//
+//	asyncp.ThenWithOptions(promise, uploadTask, asyncp.WithRetry(3))
+func ThenWithOptions(prom Promise, handler any, options ...TaskOption) Promise {
+	if p, ok := prom.(*promise); ok {
+		return p.then(handler, options...)
+	}
+	return prom.Then(handler)
+}
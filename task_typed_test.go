@@ -0,0 +1,89 @@
+package asyncp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedFuncTask(t *testing.T) {
+	type item struct {
+		Text string `json:"text"`
+	}
+	var (
+		res = ""
+		mux = NewTaskMux(
+			WithMainExecContext(context.Background()),
+			WithResponseFactory(NewProxyResponseFactory()),
+		)
+	)
+
+	prom := Handle(mux, "test", func(_ context.Context, it *item) (item, error) {
+		return item{Text: it.Text + ">1"}, nil
+	})
+	Then(prom, func(_ context.Context, it item) (*item, error) {
+		res = it.Text + ">2"
+		return nil, nil
+	})
+	Handle(mux, "error", func(_ context.Context, s string) (int, error) {
+		return 0, fmt.Errorf("error: %s", s)
+	})
+
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("test", item{Text: "test"})))
+	assert.Equal(t, "test>1>2", res)
+
+	assert.NoError(t, mux.ExecuteEvent(WithPayload("test", []byte(`{"text":"data"}`))))
+	assert.Equal(t, "data>1>2", res)
+
+	assert.EqualError(t, mux.ExecuteEvent(WithPayload("error", "test")), "error: test")
+}
+
+func TestDecodePayload(t *testing.T) {
+	type item struct {
+		Text string `json:"text"`
+		kind string
+	}
+	it, err := DecodePayload[item](&valuePayload{value: &item{Text: "ptr"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "ptr", it.Text)
+
+	// Not exported fields are kept without the encoding
+	src := item{Text: "value", kind: "in-process"}
+	ptr, err := DecodePayload[*item](&valuePayload{value: src})
+	assert.NoError(t, err)
+	if assert.NotNil(t, ptr) {
+		assert.Equal(t, "value", ptr.Text)
+		assert.Equal(t, "in-process", ptr.kind)
+		ptr.Text = "changed"
+		assert.Equal(t, "value", src.Text)
+	}
+
+	it, err = DecodePayload[item](&valuePayload{value: map[string]any{"text": "map"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "map", it.Text)
+
+	num, err := DecodePayload[int](dataPayload{bytes: []byte(`100`)})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, num)
+
+	_, err = DecodePayload[int](&valuePayload{value: "text"})
+	assert.Error(t, err)
+}
+
+func TestTypedResultNil(t *testing.T) {
+	var (
+		ptr   = newTypedResult[*int](&TaskOptions{})
+		iface = newTypedResult[any](&TaskOptions{})
+		slice = newTypedResult[[]int](&TaskOptions{SingleResponse: true})
+		num   = 1
+	)
+	assert.True(t, ptr.isNil(nil))
+	assert.False(t, ptr.isNil(&num))
+	assert.True(t, iface.isNil((*int)(nil)))
+	assert.False(t, iface.isNil([]int{}))
+	assert.True(t, slice.isNil(nil))
+	assert.Nil(t, slice.emit)
+	assert.Nil(t, newTypedResult[int](&TaskOptions{}).isNil, "int can't be nil")
+}
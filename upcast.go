@@ -0,0 +1,121 @@
+package asyncp
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// envelopeVersion of the event encoding format which is supported by consumers
+//
+//	1 - payload encoded as base64 string
+//	2 - JSON payload embedded into the `data` field as is
+const envelopeVersion = 2
+
+// defaultEnvelopeVersion of the produced events which is readable by all consumers
+const defaultEnvelopeVersion = 1
+
+// Error list of the versioning
+var (
+	ErrUnsupportedEnvelopeVersion = errors.New(`unsupported envelope version`)
+	ErrUpcasterTaken              = errors.New(`upcaster has been taken`)
+)
+
+// UpcastFnk migrates payload data encoded by the codec to the next version
+type UpcastFnk func(data []byte, c Codec) ([]byte, error)
+
+// UpcastType returns upcaster which converts decoded payload from one type to another
+//
+// Example:
+//
+//	mux.Upcast("order", 1, asyncp.UpcastType(func(o orderV1) (orderV2, error) {...}))
+func UpcastType[From, To any](fn func(From) (To, error)) UpcastFnk {
+	return func(data []byte, c Codec) ([]byte, error) {
+		var from From
+		if err := c.Unmarshal(data, &from); err != nil {
+			return nil, err
+		}
+		to, err := fn(from)
+		if err != nil {
+			return nil, err
+		}
+		return c.Marshal(to)
+	}
+}
+
+// eventUpcasters of the payload versions
+type eventUpcasters struct {
+	// steps of migration from the version to the next one
+	steps map[int]UpcastFnk
+
+	// latest version of the payload
+	latest int
+}
+
+// upcast payload data from the version to the latest one.
+// Missing steps mean no changes of the payload between versions.
+func (u *eventUpcasters) upcast(data []byte, c Codec, version int) (_ []byte, err error) {
+	for ; version < u.latest; version++ {
+		step := u.steps[version]
+		if step == nil {
+			continue
+		}
+		if data, err = step(data, c); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("upcast payload from version %d", version))
+		}
+	}
+	return data, nil
+}
+
+// Upcast registers migration of the event payload from the version to the next one.
+// Events are migrated to the latest version on decoding before they reach handlers,
+// and produced events are marked with the latest version.
+func (srv *TaskMux) Upcast(eventName string, fromVersion int, fn UpcastFnk) *TaskMux {
+	if srv.upcasters == nil {
+		srv.upcasters = map[string]*eventUpcasters{}
+	}
+	upcasters := srv.upcasters[eventName]
+	if upcasters == nil {
+		upcasters = &eventUpcasters{steps: map[int]UpcastFnk{}}
+		srv.upcasters[eventName] = upcasters
+	}
+	if _, ok := upcasters.steps[fromVersion]; ok {
+		panic(errors.Wrap(ErrUpcasterTaken, fmt.Sprintf("%s:%d", eventName, fromVersion)))
+	}
+	upcasters.steps[fromVersion] = fn
+	upcasters.latest = max(upcasters.latest, fromVersion+1)
+	return srv
+}
+
+// PayloadVersion returns the latest version of the event payload
+func (srv *TaskMux) PayloadVersion(eventName string) int {
+	if srv == nil || srv.upcasters[eventName] == nil {
+		return 0
+	}
+	return srv.upcasters[eventName].latest
+}
+
+// upcaster returns payload migration function for the event version or nil
+func (srv *TaskMux) upcaster(eventName string, version int) func(data []byte, c Codec) ([]byte, error) {
+	if srv == nil {
+		return nil
+	}
+	upcasters := srv.upcasters[eventName]
+	if upcasters == nil || version >= upcasters.latest {
+		return nil
+	}
+	return func(data []byte, c Codec) ([]byte, error) {
+		return upcasters.upcast(data, c, version)
+	}
+}
+
+// payloadVersion of the produced event
+func (srv *TaskMux) payloadVersion(ev *event) int {
+	if ev.payloadVersion > 0 {
+		return ev.payloadVersion
+	}
+	if p, _ := ev.promise.(*promise); p != nil && p.options.PayloadVersion > 0 {
+		return p.options.PayloadVersion
+	}
+	return srv.PayloadVersion(ev.name)
+}
@@ -0,0 +1,90 @@
+package asyncp
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2/codec"
+	"github.com/demdxx/asyncp/v2/schema"
+)
+
+// ErrUndefinedPayloadType in case of schema can't be derived from the handler
+var ErrUndefinedPayloadType = errors.New(`payload type of the handler is undefined`)
+
+type (
+	// Schema of the payload validation
+	Schema = schema.Schema
+
+	// ValidationError contains the list of payload mismatches with the schema
+	ValidationError = schema.ValidationError
+
+	// ValidationErrorHandlerFnk for payloads rejected by the schema
+	ValidationErrorHandlerFnk func(Task, Event, *ValidationError)
+)
+
+// payloadTypeOf returns the type of the decoded payload argument of the handler
+func payloadTypeOf(handler any) reflect.Type {
+	if h, ok := handler.(interface{ payloadType() reflect.Type }); ok {
+		return h.payloadType()
+	}
+	if _, ok := handler.(Task); ok {
+		return nil
+	}
+	ft := reflect.TypeOf(handler)
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil
+	}
+	for i := 0; i < ft.NumIn(); i++ {
+		switch inType := ft.In(i); inType {
+		case contextType, eventType, responseWriterType:
+		default:
+			return inType
+		}
+	}
+	return nil
+}
+
+// validatePayload checks JSON representation of the payload.
+// Encoded payloads of other codecs are not validated, their byte strings
+// and not string map keys have no exact JSON representation.
+func validatePayload(sch *Schema, payload Payload) error {
+	switch p := payload.(type) {
+	case nil:
+		return sch.ValidateValue(nil)
+	case *valuePayload:
+		return sch.ValidateValue(p.value)
+	}
+	if payloadContentType(payload) != codec.JSON.ContentType() {
+		return nil
+	}
+	data, err := payload.Encode()
+	if err != nil {
+		return err
+	}
+	return sch.Validate(data)
+}
+
+// validateInput payload of the event before the task execution
+func (srv *TaskMux) validateInput(prom Promise, event Event) error {
+	if p, _ := prom.(*promise); p != nil && p.options.InputSchema != nil {
+		return validatePayload(p.options.InputSchema, event.Payload())
+	}
+	return nil
+}
+
+// validateOutput payload of the response event before publishing
+func (srv *TaskMux) validateOutput(prom Promise, event Event) error {
+	if p, _ := prom.(*promise); p != nil && p.options.OutputSchema != nil {
+		return validatePayload(p.options.OutputSchema, event.Payload())
+	}
+	return nil
+}
+
+func (f TypedFuncTask[In, Out]) payloadType() reflect.Type {
+	return reflect.TypeFor[In]()
+}
+
+func (t *typedTask[In, Out]) payloadType() reflect.Type {
+	return reflect.TypeFor[In]()
+}
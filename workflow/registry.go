@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+type handlerItem struct {
+	handler any
+	options []asyncp.TaskOption
+}
+
+// HandlerRegistry binds handler names of the workflow file to the code
+type HandlerRegistry struct {
+	mx       sync.RWMutex
+	handlers map[string]handlerItem
+}
+
+// NewHandlerRegistry returns empty registry
+func NewHandlerRegistry() *HandlerRegistry {
+	return &HandlerRegistry{handlers: map[string]handlerItem{}}
+}
+
+// Register handler by name. Handler can be any value supported by TaskMux.Handle.
+// Options are applied to every task which uses the handler.
+func (r *HandlerRegistry) Register(name string, handler any, options ...asyncp.TaskOption) *HandlerRegistry {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.handlers == nil {
+		r.handlers = map[string]handlerItem{}
+	}
+	if _, ok := r.handlers[name]; ok {
+		panic(errors.Wrap(ErrHandlerRegistered, name))
+	}
+	r.handlers[name] = handlerItem{handler: handler, options: options}
+	return r
+}
+
+// Lookup handler and its options by name
+func (r *HandlerRegistry) Lookup(name string) (any, []asyncp.TaskOption, bool) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	item, ok := r.handlers[name]
+	return item.handler, item.options, ok
+}
+
+// Names of the registered handlers in sorted order
+func (r *HandlerRegistry) Names() []string {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
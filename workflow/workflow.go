@@ -0,0 +1,288 @@
+// Package workflow defines task chains of the TaskMux in YAML or JSON files
+//
+// Example:
+//
+//	streams:
+//	  sources: ["nats://nats:4222/group?topics=events"]
+//	  response: "nats://nats:4222?topics=events"
+//	tasks:
+//	  - name: rss
+//	    handler: parse_rss
+//	    retry: 2
+//	    timeout: 30s
+//	    then: [video]
+//	    steps:
+//	      - handler: extract_links
+//	  - name: video
+//	    handler: download
+//	  - name: notify
+//	    after: billing # task of other application
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+
+	"github.com/demdxx/asyncp/v2"
+	"github.com/demdxx/asyncp/v2/streams"
+)
+
+// Error list...
+var (
+	ErrHandlerRegistered = errors.New(`handler is registered`)
+	ErrUnknownHandler    = errors.New(`handler is unknown`)
+	ErrUnknownTask       = errors.New(`task is unknown`)
+	ErrDuplicateTask     = errors.New(`task is duplicated`)
+	ErrInvalidTask       = errors.New(`invalid task definition`)
+	ErrInvalidEdge       = errors.New(`invalid task edge`)
+)
+
+// Step of the anonymous task chain
+type Step struct {
+	// Handler name in the registry
+	Handler string `yaml:"handler" json:"handler"`
+
+	// Retry count of the failed execution
+	Retry int `yaml:"retry" json:"retry"`
+
+	// RetryBackoff before the second attempt, like "1s"
+	RetryBackoff time.Duration `yaml:"retry_backoff" json:"retry_backoff"`
+
+	// Timeout of the single execution attempt, like "10s"
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+}
+
+// Task definition of the workflow
+type Task struct {
+	Step `yaml:",inline"`
+
+	// Name of the task event. Handler is the same by default.
+	Name string `yaml:"name" json:"name"`
+
+	// After the parent task. If it's not defined in the file
+	// then it's external task of other application.
+	After string `yaml:"after" json:"after"`
+
+	// Then tasks of the file executed after the current one
+	// or after the last step if steps are defined
+	Then []string `yaml:"then" json:"then"`
+
+	// Steps executed one by one after the task as anonymous tasks
+	Steps []Step `yaml:"steps" json:"steps"`
+}
+
+// Streams connections of the workflow
+type Streams struct {
+	// Sources of the events
+	Sources []string `yaml:"sources" json:"sources"`
+
+	// Response stream of the task results
+	Response string `yaml:"response" json:"response"`
+}
+
+// Workflow file definition
+type Workflow struct {
+	Streams Streams `yaml:"streams" json:"streams"`
+	Tasks   []*Task `yaml:"tasks" json:"tasks"`
+
+	registry *HandlerRegistry
+	parents  map[string]string
+}
+
+// Load workflow from YAML or JSON file and check it with the registry
+func Load(filename string, registry *HandlerRegistry) (*Workflow, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	wf, err := Parse(data, registry)
+	if err != nil {
+		return nil, errors.Wrap(err, filename)
+	}
+	return wf, nil
+}
+
+// Parse workflow from YAML or JSON data and check it with the registry
+func Parse(data []byte, registry *HandlerRegistry) (*Workflow, error) {
+	var (
+		wf  Workflow
+		dec = yaml.NewDecoder(bytes.NewReader(data))
+	)
+	// JSON is the subset of YAML so both are decoded the same way
+	dec.KnownFields(true)
+	if err := dec.Decode(&wf); err != nil {
+		return nil, err
+	}
+	if err := wf.Validate(registry); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// Validate handlers and edges of the workflow.
+// All found problems are returned as one joined error.
+func (wf *Workflow) Validate(registry *HandlerRegistry) error {
+	var (
+		errs    []error
+		tasks   = make(map[string]*Task, len(wf.Tasks))
+		parents = make(map[string]string, len(wf.Tasks))
+	)
+	checkStep := func(name string, step *Step) {
+		if _, _, ok := registry.Lookup(step.Handler); !ok {
+			errs = append(errs, errors.Wrap(ErrUnknownHandler, name+": "+step.Handler))
+		}
+		if step.Retry < 0 || step.RetryBackoff < 0 || step.Timeout < 0 {
+			errs = append(errs, errors.Wrap(ErrInvalidTask, name+": negative retry or timeout"))
+		}
+	}
+	setParent := func(name, parent string) {
+		if prev, ok := parents[name]; ok && prev != parent {
+			errs = append(errs, errors.Wrap(ErrInvalidEdge,
+				fmt.Sprintf("%s: several parents %s and %s", name, prev, parent)))
+			return
+		}
+		parents[name] = parent
+	}
+
+	for i, task := range wf.Tasks {
+		switch {
+		case task == nil || task.Name == "":
+			errs = append(errs, errors.Wrap(ErrInvalidTask, fmt.Sprintf("task #%d: empty name", i)))
+			continue
+		case strings.ContainsAny(task.Name, ">@"):
+			errs = append(errs, errors.Wrap(ErrInvalidTask, task.Name+": name can't contain '>' or '@'"))
+			continue
+		case tasks[task.Name] != nil:
+			errs = append(errs, errors.Wrap(ErrDuplicateTask, task.Name))
+			continue
+		}
+		if task.Handler == "" {
+			task.Handler = task.Name
+		}
+		tasks[task.Name] = task
+		checkStep(task.Name, &task.Step)
+		for j := range task.Steps {
+			checkStep(fmt.Sprintf("%s.%d", task.Name, j+1), &task.Steps[j])
+		}
+	}
+
+	for _, task := range wf.Tasks {
+		if task == nil || tasks[task.Name] != task {
+			continue
+		}
+		if task.After != "" {
+			if task.After == task.Name || strings.Contains(task.After, ">") {
+				errs = append(errs, errors.Wrap(ErrInvalidEdge, task.Name+": after "+task.After))
+			} else {
+				setParent(task.Name, task.After)
+			}
+		}
+		for _, next := range task.Then {
+			switch {
+			case next == task.Name:
+				errs = append(errs, errors.Wrap(ErrInvalidEdge, task.Name+": then itself"))
+			case tasks[next] == nil:
+				errs = append(errs, errors.Wrap(ErrUnknownTask, task.Name+": then "+next))
+			default:
+				setParent(next, task.Name)
+			}
+		}
+	}
+
+	// Every task has the single parent so the cycle is found by the parent chain
+	for name := range tasks {
+		for parent, depth := parents[name], 0; parent != ""; parent, depth = parents[parent], depth+1 {
+			if parent == name || depth > len(tasks) {
+				errs = append(errs, errors.Wrap(ErrInvalidEdge, name+": cycle"))
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return multierr.Combine(errs...)
+	}
+	wf.registry = registry
+	wf.parents = parents
+	return nil
+}
+
+// NewTaskMux creates the mux with the workflow tasks.
+// The response stream of the workflow is used if it's defined.
+// FinishInit should be called after the mux setup.
+func (wf *Workflow) NewTaskMux(ctx context.Context, options ...asyncp.Option) (*asyncp.TaskMux, error) {
+	if wf.Streams.Response != "" {
+		pub, err := streams.PublisherFromURL(ctx, wf.Streams.Response)
+		if err != nil {
+			return nil, errors.Wrap(err, "response stream")
+		}
+		options = append(options, asyncp.WithStreamResponsePublisher(pub))
+	}
+	mux := asyncp.NewTaskMux(options...)
+	if err := wf.Register(mux); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// Register workflow tasks in the mux. Parents are registered before children.
+func (wf *Workflow) Register(mux *asyncp.TaskMux) error {
+	if wf.registry == nil {
+		return errors.Wrap(ErrInvalidTask, "workflow is not validated")
+	}
+	var (
+		tasks      = make(map[string]*Task, len(wf.Tasks))
+		registered = map[string]bool{}
+		register   func(task *Task)
+	)
+	for _, task := range wf.Tasks {
+		tasks[task.Name] = task
+	}
+	register = func(task *Task) {
+		if registered[task.Name] {
+			return
+		}
+		registered[task.Name] = true
+		name := task.Name
+		if parent := wf.parents[task.Name]; parent != "" {
+			if parentTask := tasks[parent]; parentTask != nil {
+				register(parentTask)
+			}
+			name = parent + ">" + name
+		}
+		handler, options := wf.handler(&task.Step)
+		prom := mux.Handle(name, handler, options...)
+		for i := range task.Steps {
+			handler, options := wf.handler(&task.Steps[i])
+			prom = asyncp.ThenWithOptions(prom, handler, options...)
+		}
+	}
+	for _, task := range wf.Tasks {
+		register(task)
+	}
+	return nil
+}
+
+// ListenAndServe the mux with the workflow sources
+func (wf *Workflow) ListenAndServe(ctx context.Context, mux *asyncp.TaskMux) error {
+	sources := make([]any, 0, len(wf.Streams.Sources))
+	for _, src := range wf.Streams.Sources {
+		sources = append(sources, src)
+	}
+	return streams.ListenAndServe(ctx, mux, sources...)
+}
+
+func (wf *Workflow) handler(step *Step) (any, []asyncp.TaskOption) {
+	handler, options, _ := wf.registry.Lookup(step.Handler)
+	options = append(options[:len(options):len(options)],
+		asyncp.WithRetry(step.Retry), asyncp.WithRetryBackoff(step.RetryBackoff), asyncp.WithTimeout(step.Timeout))
+	return handler, options
+}
@@ -0,0 +1,123 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/asyncp/v2"
+)
+
+const testWorkflow = `
+tasks:
+  - name: video
+    after: rss
+    handler: download
+  - name: rss
+    handler: parse
+    retry: 2
+    retry_backoff: 1ms
+    timeout: 1s
+    then: [video]
+    steps:
+      - handler: double
+  - name: notify
+    after: billing
+`
+
+func testRegistry(result *[]string) *HandlerRegistry {
+	attempts := 0
+	return NewHandlerRegistry().
+		Register("parse", func(s string) (*string, error) {
+			if attempts++; attempts < 2 {
+				return nil, errors.New("temporary")
+			}
+			s += ":parse"
+			return &s, nil
+		}).
+		Register("double", func(s string) string { return s + ":double" }).
+		Register("download", func(s string) error {
+			*result = append(*result, s)
+			return nil
+		}).
+		Register("notify", func(s string) error { return nil })
+}
+
+func TestWorkflowParse(t *testing.T) {
+	var result []string
+	wf, err := Parse([]byte(testWorkflow), testRegistry(&result))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, wf.Tasks[1].Retry)
+	assert.Equal(t, time.Second, wf.Tasks[1].Timeout)
+	assert.Equal(t, "notify", wf.Tasks[2].Handler)
+
+	mux, err := wf.NewTaskMux(context.Background(),
+		asyncp.WithResponseFactory(asyncp.NewProxyResponseFactory()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, mux.ExecuteEvent(asyncp.WithPayload("rss", "url")))
+	assert.Equal(t, []string{"url:parse:double"}, result)
+
+	g := mux.TaskGraph()
+	assert.NotNil(t, g.Node("rss.1"))
+	assert.NotNil(t, g.Node("video"))
+}
+
+func TestWorkflowLoadJSON(t *testing.T) {
+	var (
+		result   []string
+		filename = filepath.Join(t.TempDir(), "workflow.json")
+	)
+	assert.NoError(t, os.WriteFile(filename, []byte(`{
+		"streams": {"sources": ["gochan://test"]},
+		"tasks": [{"name": "rss", "handler": "parse", "retry": 1, "then": ["video"]},
+		          {"name": "video", "handler": "download"}]
+	}`), 0o600))
+	wf, err := Load(filename, testRegistry(&result))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"gochan://test"}, wf.Streams.Sources)
+		assert.Equal(t, "rss", wf.parents["video"])
+	}
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	var result []string
+	_, err := Parse([]byte(`
+tasks:
+  - name: rss
+    handler: unknown
+    then: [video, missing, rss]
+    steps:
+      - handler: nope
+  - name: video
+    handler: download
+    after: other
+  - name: a
+    handler: download
+    after: b
+  - name: b
+    handler: download
+    after: a
+  - name: a
+    handler: download
+`), testRegistry(&result))
+	assert.ErrorIs(t, err, ErrUnknownHandler)
+	assert.ErrorIs(t, err, ErrUnknownTask)
+	assert.ErrorIs(t, err, ErrInvalidEdge)
+	assert.ErrorIs(t, err, ErrDuplicateTask)
+	assert.Contains(t, err.Error(), "rss: unknown")
+	assert.Contains(t, err.Error(), "rss.1: nope")
+	assert.Contains(t, err.Error(), "video: several parents")
+	assert.Contains(t, err.Error(), "a: cycle")
+
+	_, err = Parse([]byte("tasks:\n  - name: rss\n    handler: parse\n    retries: 1\n"), testRegistry(&result))
+	assert.Error(t, err, "unknown fields should be rejected")
+}